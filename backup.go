@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is used in the file names of the backups. It sorts
+// chronologically and is precise enough for autosaving after every change.
+const backupTimeFormat = "20060102-150405.000"
+
+type argRestore struct {
+	File   string `arg:"--file,-f" help:"specify the file that should be restored"`
+	Backup string `arg:"positional" help:"the backup to restore, either its number as listed or its path. If omitted, the backups are listed"`
+}
+
+// backup is a copy of a file made before writing it.
+type backup struct {
+	path string
+	time time.Time
+}
+
+// writeFileAtomic writes the content to a temporary file which then replaces
+// the file, so the file is never left half written. The mode of an existing
+// file is kept, new files are created with the given mode.
+func writeFileAtomic(path string, content []byte, mode fs.FileMode) error {
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// no-op after the rename succeeded
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// persist the rename, not supported on every platform
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// listBackups returns the backups of the file, the newest first.
+func listBackups(path string) ([]backup, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	// the directory is listed instead of globbing, as the name of the file
+	// might contain characters which have a meaning in patterns
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	for _, e := range entries {
+		stamp, ok := strings.CutPrefix(e.Name(), base+".")
+		if !ok || e.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, ".bak")
+		if !ok {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: path + "." + stamp + ".bak", time: t})
+	}
+	slices.SortFunc(backups, func(a, b backup) int {
+		return b.time.Compare(a.time)
+	})
+	return backups, nil
+}
+
+// backupFile copies the file to a new timestamped backup and removes the
+// oldest backups, so at most keep backups exist. Nothing is done if keep is
+// zero or the file doesn't exist.
+func backupFile(path string, keep int) error {
+	if keep == 0 || !exists(path) {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	name := path + "." + time.Now().Format(backupTimeFormat) + ".bak"
+	if err := writeFileAtomic(name, content, fi.Mode().Perm()); err != nil {
+		return err
+	}
+
+	backups, err := listBackups(path)
+	if err != nil {
+		return err
+	}
+	for len(backups) > keep {
+		if err := os.Remove(backups[len(backups)-1].path); err != nil {
+			return err
+		}
+		backups = backups[:len(backups)-1]
+	}
+	return nil
+}
+
+// findBackup resolves the backup by its number in the listing or its path.
+func findBackup(backups []backup, ref string) (backup, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(backups) {
+			return backup{}, fmt.Errorf("no backup number %d", n)
+		}
+		return backups[n-1], nil
+	}
+	for _, b := range backups {
+		if b.path == ref || filepath.Base(b.path) == ref {
+			return b, nil
+		}
+	}
+	return backup{}, fmt.Errorf("no backup %q", ref)
+}
+
+// restoreBackup lists the backups of the file or replaces the file with one
+// of them. The current content is backed up as well, so restoring can be
+// undone, none of the existing backups is removed for it.
+func restoreBackup(args *argRestore, cfg config) error {
+	path := filePath(args.File)
+	backups, err := listBackups(path)
+	if err != nil {
+		return err
+	}
+	if args.Backup == "" {
+		if len(backups) == 0 {
+			fmt.Println("no backups of " + path)
+		}
+		for i, b := range backups {
+			fmt.Printf("%d\t%s\t%s\n", i+1, b.time.Format("2006-01-02 15:04:05"), b.path)
+		}
+		return nil
+	}
+
+	b, err := findBackup(backups, args.Backup)
+	if err != nil {
+		return err
+	}
+	// refuse to restore something which can't be read afterwards
+	if _, _, errK := loadData(b.path, cfg.stages); errK != nil {
+		return errK
+	}
+	content, err := os.ReadFile(b.path)
+	if err != nil {
+		return err
+	}
+
+	if errK := acquireLock(path); errK != nil {
+		return errK
+	}
+	defer releaseLock(path)
+	// keep all existing backups, the restored one in particular, unless
+	// backups are disabled
+	keep := 0
+	if cfg.backups > 0 {
+		keep = max(cfg.backups, len(backups)+1)
+	}
+	if err := backupFile(path, keep); err != nil {
+		return fmt.Errorf("making backup before restoring failed: %w", err)
+	}
+	if err := writeFileAtomic(path, content, 0o644); err != nil {
+		return err
+	}
+	fmt.Println("restored " + b.path)
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ktask/ktask"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.ktask")
+	require.Nil(t, writeFileAtomic(path, []byte("todo\n"), 0o600))
+	written, _ := os.ReadFile(path)
+	assert.Equal(t, "todo\n", string(written))
+	fi, _ := os.Stat(path)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	// the mode of an existing file is kept
+	require.Nil(t, os.Chmod(path, 0o640))
+	require.Nil(t, writeFileAtomic(path, []byte("done\n"), 0o600))
+	written, _ = os.ReadFile(path)
+	assert.Equal(t, "done\n", string(written))
+	fi, _ = os.Stat(path)
+	assert.Equal(t, os.FileMode(0o640), fi.Mode().Perm())
+
+	// no temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, entries, 1)
+}
+
+func TestListBackups(t *testing.T) {
+	// the name contains characters which have a meaning in glob patterns
+	path := filepath.Join(t.TempDir(), "tasks [*?].ktask")
+	for _, name := range []string{
+		"tasks [*?].ktask.20240102-100000.000.bak",
+		"tasks [*?].ktask.20240103-100000.000.bak",
+		"tasks [*?].ktask.20240101-100000.000.bak",
+		"tasks [*?].ktask.garbage.bak",
+		"tasks x.ktask.20240104-100000.000.bak",
+		"tasks [*?].ktask.20240105-100000.000.tmp",
+	} {
+		require.Nil(t, os.WriteFile(filepath.Join(filepath.Dir(path), name), nil, 0o644))
+	}
+	backups, err := listBackups(path)
+	require.Nil(t, err)
+	require.Len(t, backups, 3)
+	for i, day := range []int{3, 2, 1} {
+		assert.Equal(t, time.Date(2024, 1, day, 10, 0, 0, 0, time.Local), backups[i].time)
+		assert.Equal(t, path+backups[i].time.Format(".20060102-150405.000.bak"), backups[i].path)
+	}
+}
+
+func TestBackupFileRemovesOldestBackups(t *testing.T) {
+	path := tempFile(t, "todo\n")
+	for _, stamp := range []string{"20240101-100000.000", "20240102-100000.000"} {
+		require.Nil(t, os.WriteFile(path+"."+stamp+".bak", nil, 0o644))
+	}
+	require.Nil(t, backupFile(path, 2))
+	backups, _ := listBackups(path)
+	require.Len(t, backups, 2)
+	content, _ := os.ReadFile(backups[0].path)
+	assert.Equal(t, "todo\n", string(content))
+	assert.Equal(t, path+".20240102-100000.000.bak", backups[1].path)
+
+	// nothing is done if backups are disabled
+	require.Nil(t, backupFile(path, 0))
+	backups, _ = listBackups(path)
+	assert.Len(t, backups, 2)
+}
+
+func TestWriteDataBacksUpOncePerLock(t *testing.T) {
+	path := tempFile(t, "todo\n")
+	data, file, errK := readData(path, nil, false)
+	require.Nil(t, errK)
+	defer releaseLock(path)
+
+	for _, stage := range []string{"doing", "done", "waiting"} {
+		data = append(data, ktask.NewRecord(ktask.Stage(stage)))
+		_, _, err := writeData(file, data, 2)
+		require.Nil(t, err)
+	}
+	backups, _ := listBackups(path)
+	require.Len(t, backups, 1)
+	content, _ := os.ReadFile(backups[0].path)
+	assert.Equal(t, "todo\n", string(content))
+}
+
+func TestRestoreBackupKeepsExistingBackups(t *testing.T) {
+	path := tempFile(t, "done\n")
+	oldest := path + ".20240101-100000.000.bak"
+	require.Nil(t, os.WriteFile(oldest, []byte("todo\n"), 0o644))
+	require.Nil(t, os.WriteFile(path+".20240102-100000.000.bak", []byte("doing\n"), 0o644))
+
+	require.Nil(t, restoreBackup(&argRestore{File: path, Backup: "2"}, config{backups: 1}))
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "todo\n", string(content))
+
+	// the restored backup and the replaced content are kept
+	backups, _ := listBackups(path)
+	require.Len(t, backups, 3)
+	assert.Equal(t, oldest, backups[2].path)
+	content, _ = os.ReadFile(backups[0].path)
+	assert.Equal(t, "done\n", string(content))
+
+	// no backup is made if backups are disabled
+	require.Nil(t, restoreBackup(&argRestore{File: path, Backup: "1"}, config{backups: 0}))
+	content, _ = os.ReadFile(path)
+	assert.Equal(t, "done\n", string(content))
+	backups, _ = listBackups(path)
+	assert.Len(t, backups, 3)
+}
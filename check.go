@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"strings"
+	"time"
+)
+
+type argCheck struct {
+	File string `arg:"positional" help:"specify the file that should be checked"`
+}
+
+// checkRecords finds entries which are syntactically correct but most likely
+// wrong, such as entries modified before they were created, entries without
+// or with the same title, entries sharing an id and dates in the future.
+func checkRecords(data []ktask.Record, now time.Time) []string {
+	var problems []string
+	today := now.Format(dateFormat)
+	titles, ids := map[string][]string{}, map[string][]string{}
+	var order, idOrder []string
+	for _, r := range data {
+		for i, e := range r.Entries() {
+			ref := entryRef(r, i)
+			created, modified := e.CreatedAt().Format(dateFormat), e.ModifiedAt().Format(dateFormat)
+			if modified < created {
+				problems = append(problems, fmt.Sprintf("%s: modified (%s) before it was created (%s)", ref, modified, created))
+			}
+			if created > today {
+				problems = append(problems, fmt.Sprintf("%s: created in the future (%s)", ref, created))
+			}
+			if modified > today {
+				problems = append(problems, fmt.Sprintf("%s: modified in the future (%s)", ref, modified))
+			}
+
+			if id := e.ID(); id != "" {
+				if len(ids[id]) == 0 {
+					idOrder = append(idOrder, id)
+				}
+				// the id doesn't tell the entries apart, their position does
+				ids[id] = append(ids[id], fmt.Sprintf("%s:%d", r.Stage(), i+1))
+			}
+
+			title := strings.Join(e.Name().Lines(), " ")
+			if strings.TrimSpace(title) == "" {
+				problems = append(problems, fmt.Sprintf("%s: no title", ref))
+				continue
+			}
+			if len(titles[title]) == 0 {
+				order = append(order, title)
+			}
+			titles[title] = append(titles[title], ref)
+		}
+	}
+	for _, title := range order {
+		if refs := titles[title]; len(refs) > 1 {
+			problems = append(problems, fmt.Sprintf("%s: duplicate title %q", strings.Join(refs, ", "), title))
+		}
+	}
+	for _, id := range idOrder {
+		if refs := ids[id]; len(refs) > 1 {
+			problems = append(problems, fmt.Sprintf("%s: duplicate id %q", strings.Join(refs, ", "), id))
+		}
+	}
+	return problems
+}
+
+// checkData reports syntax errors and semantic problems of the file.
+func checkData(args *argCheck, cfg config) error {
+	path := filePath(args.File)
+	data, _, errK := loadData(path, cfg.stages)
+	if errK != nil {
+		return errK
+	}
+	problems := checkRecords(data, time.Now())
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return ktask.NewErrorWithCode(
+			ktask.LOGICAL_ERROR,
+			fmt.Sprintf("%d problem(s) found", len(problems)),
+			"Location: "+path,
+			nil,
+		)
+	}
+	return nil
+}
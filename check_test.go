@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"ktask/ktask"
+	"ktask/ktask/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorCode returns the code the application exits with after the error, 0 if
+// there was none.
+func errorCode(err error) ktask.Code {
+	var errK ktask.Error
+	if errors.As(err, &errK) {
+		return errK.Code()
+	}
+	if err != nil {
+		return ktask.GENERAL_ERROR
+	}
+	return 0
+}
+
+func TestCheckRecords(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		text   string
+		expect []string
+	}{
+		{"todo\n    2024-01-02 2024-01-03 buy milk\n", nil},
+		{"todo\n    2024-01-02 2024-01-03\n        notes\n", []string{"todo:1: no title"}},
+		{"todo\n    2024-01-05 2024-01-03 buy milk\n", []string{"todo:1: modified (2024-01-03) before it was created (2024-01-05)"}},
+		{"todo\n    2024-01-02 2024-01-11 buy milk\n", []string{"todo:1: modified in the future (2024-01-11)"}},
+		{"todo\n    2024-01-11 2024-01-12 buy milk #id=aaaaaa\n", []string{
+			"aaaaaa: created in the future (2024-01-11)",
+			"aaaaaa: modified in the future (2024-01-12)",
+		}},
+		{"todo\n    2024-01-02 2024-01-03 buy milk\n\ndone\n    2024-01-01 2024-01-02 buy milk\n", []string{`todo:1, done:1: duplicate title "buy milk"`}},
+		{"todo\n    2024-01-02 2024-01-03 buy milk\n    2024-01-02 2024-01-03 buy\n        milk\n", nil},
+		{"todo\n    2024-01-02 2024-01-03 buy milk #id=aaaaaa\n    2024-01-02 2024-01-03 call mom #id=aaaaaa\n", []string{`todo:1, todo:2: duplicate id "aaaaaa"`}},
+	} {
+		rs, _, errs := parser.NewSerialParser().Parse(test.text)
+		require.Nil(t, errs, test.text)
+		assert.Equal(t, test.expect, checkRecords(rs, now), test.text)
+	}
+}
+
+func TestCheckDataExitCodes(t *testing.T) {
+	for _, test := range []struct {
+		text   string
+		expect ktask.Code
+	}{
+		{"todo\n    2024-01-02 2024-01-03 buy milk\n", 0},
+		{"todo\n    2024-01-02 2024-01-03 buy milk #due=tomorrow\n", ktask.LOGICAL_ERROR},
+		{"todo\n    2024-01-02 2024-01-03 buy milk #prio=1\n", ktask.LOGICAL_ERROR},
+		{"todo\n    2024-01-02 2024-01-03 buy milk #id=aaaaaa\n    2024-01-02 2024-01-03 call mom #id=aaaaaa\n", ktask.LOGICAL_ERROR},
+	} {
+		var err error
+		captureStdout(t, func() {
+			err = checkData(&argCheck{File: tempFile(t, test.text)}, config{})
+		})
+		assert.Equal(t, test.expect, errorCode(err), test.text)
+	}
+
+	err := checkData(&argCheck{File: t.TempDir() + "/missing.ktask"}, config{})
+	assert.Equal(t, ktask.NO_INPUT_ERROR, errorCode(err))
+}
+
+func TestCheckDataReportsParserErrors(t *testing.T) {
+	path := tempFile(t, "todo\n    2024-01-02 2024-01-03 buy milk #due=tomorrow\n    2024-01-02 2024-01-03 call mom #prio=1\n")
+	err := checkData(&argCheck{File: path}, config{})
+	pErrs, ok := err.(ktask.ParserErrors)
+	require.True(t, ok, err)
+	require.Len(t, pErrs.All(), 2)
+	assert.Equal(t, "ErrorMalformedDueDate", pErrs.All()[0].Code())
+	assert.Equal(t, "ErrorMalformedPriority", pErrs.All()[1].Code())
+}
+
+func TestFormatDataExitCodes(t *testing.T) {
+	for _, test := range []struct {
+		text   string
+		expect ktask.Code
+	}{
+		{"todo\n\t2024-01-02 2024-01-03 buy milk\n", 0},
+		{"todo\n    2024-01-02 2024-01-03 buy milk #due=tomorrow\n", ktask.LOGICAL_ERROR},
+		{"todo\n    2024-01-02 2024-01-03 buy milk #prio=1\n", ktask.LOGICAL_ERROR},
+	} {
+		var err error
+		captureStdout(t, func() {
+			err = formatData(&argFmt{File: tempFile(t, test.text)}, config{})
+		})
+		assert.Equal(t, test.expect, errorCode(err), test.text)
+	}
+
+	err := formatData(&argFmt{File: t.TempDir() + "/missing.ktask"}, config{})
+	assert.Equal(t, ktask.NO_INPUT_ERROR, errorCode(err))
+}
+
+func TestFormatDataWrite(t *testing.T) {
+	path := tempFile(t, "// groceries\ntodo\n\t2024-01-02 2024-01-03 buy milk\n")
+	out := captureStdout(t, func() {
+		require.Nil(t, formatData(&argFmt{File: path, Diff: true}, config{}))
+	})
+	assert.Contains(t, out, "-\t2024-01-02 2024-01-03 buy milk\n+    2024-01-02 2024-01-03 buy milk\n")
+
+	require.Nil(t, formatData(&argFmt{File: path, Write: true}, config{}))
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "// groceries\ntodo\n    2024-01-02 2024-01-03 buy milk\n", string(content))
+}
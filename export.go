@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"ktask/ktask"
+	"ktask/ktask/parser"
+	"ktask/ktask/parser/csv"
+	"ktask/ktask/parser/json"
+	"ktask/ktask/parser/todotxt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type argExport struct {
+	File           string `arg:"positional" help:"specify the file that should be exported"`
+	Format         string `arg:"--format" default:"json" help:"output format, one of json, csv, todotxt, markdown or html"`
+	Output         string `arg:"--output,-o" help:"write the export to this file instead of stdout"`
+	Pretty         bool   `arg:"--pretty" help:"pretty-print the json output"`
+	Table          bool   `arg:"--table" help:"render the entries of the markdown output as tables"`
+	ModifiedAfter  date   `arg:"--modified-after" help:"only export entries modified at or after this date (YYYY-MM-DD)"`
+	ModifiedBefore date   `arg:"--modified-before" help:"only export entries modified at or before this date (YYYY-MM-DD)"`
+}
+
+type argImport struct {
+	Input   string   `arg:"positional" help:"the file to import, reads from stdin if omitted or -"`
+	File    string   `arg:"--file,-f" help:"specify the file the entries are imported into"`
+	Format  string   `arg:"--format" default:"json" help:"input format, one of json, csv or todotxt"`
+	Map     []string `arg:"--map,separate" help:"map a column of the csv input to a ktask column in the form <header>=<column>, may be specified multiple times"`
+	Replace bool     `arg:"--replace" help:"replace the content of the file instead of adding the imported entries"`
+}
+
+// exportData writes the records of the file in another format.
+func exportData(args *argExport, cfg config) error {
+	data, stages, errK := loadData(filePath(args.File), cfg.stages)
+	if errK != nil {
+		return errK
+	}
+
+	var filtered []ktask.Record
+	for _, r := range data {
+		r, _ = r.SplitOnFunc(func(e *ktask.Entry) bool {
+			return inRange(e.ModifiedAt(), args.ModifiedAfter, args.ModifiedBefore)
+		})
+		filtered = append(filtered, r)
+	}
+
+	var out string
+	switch args.Format {
+	case "json":
+		out = json.ToJson(filtered, nil, args.Pretty) + "\n"
+	case "csv":
+		out = csv.ToCsv(filtered)
+	case "todotxt":
+		out = todotxt.ToTodoTxt(filtered, stages.Done())
+	case "markdown":
+		out = parser.NewMarkdownRenderer(args.Table, stages.Done()).Render(filtered...)
+	case "html":
+		out = parser.NewHTMLRenderer(filepath.Base(filePath(args.File))).Render(filtered...)
+	default:
+		return fmt.Errorf("unknown export format %q", args.Format)
+	}
+
+	if args.Output == "" || args.Output == "-" {
+		fmt.Print(out)
+		return nil
+	}
+	return writeFileAtomic(args.Output, []byte(out), 0o644)
+}
+
+// importData reads records in another format and adds their entries to the
+// file, or replaces its content.
+func importData(args *argImport, cfg config) error {
+	var input []byte
+	var err error
+	if args.Input == "" || args.Input == "-" {
+		input, err = io.ReadAll(os.Stdin)
+	} else {
+		input, err = os.ReadFile(args.Input)
+	}
+	if err != nil {
+		return ktask.NewErrorWithCode(ktask.NO_INPUT_ERROR, "Error reading the import", "Location: "+args.Input, err)
+	}
+	path := filePath(args.File)
+	// completed todo.txt lines go to the last stage of the file
+	stages := cfg.stages
+	if content, err := os.ReadFile(path); err == nil {
+		var errK ktask.Error
+		if stages, errK = fileStages(string(content), cfg.stages); errK != nil {
+			return errK
+		}
+	}
+
+	var imported []ktask.Record
+	switch args.Format {
+	case "json":
+		imported, err = json.FromJson(input)
+	case "csv":
+		mapping := map[string]string{}
+		for _, m := range args.Map {
+			from, to, ok := strings.Cut(m, "=")
+			if !ok {
+				return fmt.Errorf("invalid column mapping %q, expected <header>=<column>", m)
+			}
+			mapping[from] = to
+		}
+		imported, err = csv.FromCsv(input, mapping)
+	case "todotxt":
+		imported, err = todotxt.FromTodoTxt(input, stages.Todo(), stages.Done(), time.Now())
+	default:
+		return fmt.Errorf("unknown import format %q", args.Format)
+	}
+	if err != nil {
+		return ktask.NewErrorWithCode(ktask.LOGICAL_ERROR, "Invalid import", err.Error(), err)
+	}
+	if !exists(path) {
+		if err := writeFileAtomic(path, nil, 0o644); err != nil {
+			return err
+		}
+	}
+	count := 0
+	err = modifyData(path, cfg, func(data []ktask.Record, stages ktask.Stages) ([]ktask.Record, error) {
+		if args.Replace {
+			data = nil
+		}
+		data, count, err = addRecords(data, stages, imported)
+		return stages.Arrange(data), err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d entries into %s\n", count, path)
+	return nil
+}
+
+// addRecords adds the entries of the records to the stages of the same name,
+// stages which don't exist yet are appended. Entries get a new identifier if
+// theirs is used already in the file. It returns the number of entries added.
+func addRecords(data []ktask.Record, stages ktask.Stages, rs []ktask.Record) ([]ktask.Record, int, error) {
+	count := 0
+	for _, r := range rs {
+		var ri int
+		var err error
+		data, ri, err = stageRecord(data, stages, r.Stage())
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, e := range r.Entries() {
+			if ktask.FindByID(e.ID(), data...) != nil {
+				e.SetID(ktask.NewID(append(data, rs...)...))
+			}
+			data[ri].AppendEntry(e)
+			count++
+		}
+	}
+	return data, count, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ktask/ktask"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportContent = `todo
+    2024-01-02 2024-01-02 buy milk
+    2024-01-03 2024-01-05 water plants
+
+done
+    2024-01-01 2024-01-06 celebrate
+`
+
+func TestExportDataFiltersAllFormats(t *testing.T) {
+	path := tempFile(t, exportContent)
+	for _, format := range []string{"json", "csv", "todotxt", "markdown", "html"} {
+		out := captureStdout(t, func() {
+			require.Nil(t, exportData(&argExport{File: path, Format: format, ModifiedAfter: day(2024, 1, 5)}, config{}))
+		})
+		assert.Contains(t, out, "water plants", format)
+		assert.Contains(t, out, "celebrate", format)
+		assert.NotContains(t, out, "buy milk", format)
+	}
+}
+
+func TestImportDataKeepsTheFileReadable(t *testing.T) {
+	for _, test := range []struct {
+		format string
+		input  string
+		expect string
+	}{
+		{"csv", "stage,created,title,notes\ntodo,2024-01-02,\"buy\r\nmilk\n\",\"two\nbottles\"\n", "todo\n    2024-01-02 2024-01-02 buy milk\n        two\n        bottles\n"},
+		{"json", `{"records": [{"stage": "todo", "entries": [{"name": ["buy milk", "two bottles"], "created_at": "2024-01-02"}]}]}`, "todo\n    2024-01-02 2024-01-02 buy milk\n        two bottles\n"},
+	} {
+		input := filepath.Join(t.TempDir(), "input")
+		path := filepath.Join(t.TempDir(), "tasks.ktask")
+		require.Nil(t, os.WriteFile(input, []byte(test.input), 0o644))
+		captureStdout(t, func() {
+			require.Nil(t, importData(&argImport{File: path, Input: input, Format: test.format}, config{}), test.input)
+		})
+		content, _ := os.ReadFile(path)
+		assert.Equal(t, test.expect, string(content), test.input)
+	}
+}
+
+func TestImportDataRejectsCommentsAsStages(t *testing.T) {
+	for _, test := range []struct {
+		format string
+		input  string
+	}{
+		{"json", `{"records": [{"stage": "// hi", "entries": [{"title": "x", "created_at": "2024-01-02"}]}]}`},
+		{"csv", "stage,created,title\n; hi,2024-01-02,x\n"},
+		{"todotxt", "2024-01-02 x stage:\"// hi\"\n"},
+	} {
+		input := filepath.Join(t.TempDir(), "input")
+		require.Nil(t, os.WriteFile(input, []byte(test.input), 0o644))
+		path := tempFile(t, "todo\n")
+		err := importData(&argImport{File: path, Input: input, Format: test.format}, config{})
+		assert.Equal(t, ktask.LOGICAL_ERROR, errorCode(err), test.format)
+		content, _ := os.ReadFile(path)
+		assert.Equal(t, "todo\n", string(content), test.format)
+	}
+}
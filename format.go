@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"ktask/ktask/parser"
+	"os"
+
+	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type argFmt struct {
+	File  string `arg:"positional" help:"specify the file that should be formatted"`
+	Write bool   `arg:"--write,-w" help:"write the result back to the file instead of printing it"`
+	Diff  bool   `arg:"--diff,-d" help:"print the changes as unified diff instead of the result"`
+}
+
+// diff returns the unified diff of the two texts, which is empty if they are
+// equal.
+func diff(path string, a, b string) string {
+	d, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	})
+	return d
+}
+
+// formatData brings the file into the canonical form.
+func formatData(args *argFmt, cfg config) error {
+	path := filePath(args.File)
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return ktask.NewErrorWithCode(ktask.NO_INPUT_ERROR, "Error reading file", "Location: "+path, err)
+	}
+	stages, errK := fileStages(string(original), cfg.stages)
+	if errK != nil {
+		return errK
+	}
+	// the records keep the order of the file, so that e.g. the comments at its
+	// head stay there and no empty declared stages are added
+	data, _, errs := parser.NewSerialParserWithStages(stages).Parse(string(original))
+	if errs != nil {
+		return ktask.NewParserErrors(errs)
+	}
+	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	formatted := parser.SerialiseRecords(ser, data...).ToString()
+
+	if args.Diff {
+		fmt.Print(diff(path, string(original), formatted))
+	}
+	if !args.Write {
+		if !args.Diff {
+			fmt.Print(formatted)
+		}
+		return nil
+	}
+	if formatted == string(original) {
+		return nil
+	}
+
+	if errK := acquireLock(path); errK != nil {
+		return errK
+	}
+	defer releaseLock(path)
+	if err := backupFile(path, cfg.backups); err != nil {
+		return fmt.Errorf("making backup before writing failed: %w", err)
+	}
+	return writeFileAtomic(path, []byte(formatted), 0o644)
+}
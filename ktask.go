@@ -1,19 +1,23 @@
 package main
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io/fs"
 	"ktask/ktask"
 	"ktask/ktask/kanban"
 	"ktask/ktask/parser"
 	"os"
 	"path/filepath"
 	"slices"
-	"strings"
+	"syscall"
+	"time"
 
 	arg "github.com/alexflint/go-arg"
 	tea "github.com/charmbracelet/bubbletea"
 	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
+	"github.com/jotaen/klog/klog/parser/txt"
 	gap "github.com/muesli/go-app-paths"
 )
 
@@ -55,125 +59,412 @@ func exists(path string) bool {
 	return true
 }
 
-func readData(source string) ([]ktask.Record, ktask.Error) {
-	lock := source + ".lock"
-	if exists(lock) {
-		return nil, ktask.NewError(
-			"Lock file exists",
-			lock,
-			errors.New("file exists"),
-		)
-	}
+// loadData reads and parses the file without locking it. It returns the
+// records and the stages the file was read with, see fileStages.
+func loadData(source string, stages ktask.Stages) ([]ktask.Record, ktask.Stages, ktask.Error) {
 	content, err := os.ReadFile(source)
 	if err != nil {
-		return nil, ktask.NewErrorWithCode(
+		return nil, nil, ktask.NewErrorWithCode(
 			ktask.NO_INPUT_ERROR,
 			"Error reading file",
 			"Location: "+source,
 			err,
 		)
 	}
-	f, err := os.Create(lock)
-	if err == nil {
-		f.Close()
+	stages, errK := fileStages(string(content), stages)
+	if errK != nil {
+		return nil, nil, errK
 	}
+	records, _, errK := parseData(string(content), stages)
+	return records, stages, errK
+}
 
-	records, _, errs := parser.NewSerialParser().Parse(string(content))
+// fileStages returns the stages the content is read with. The stages given on
+// the command line override the ones declared in the content.
+func fileStages(content string, stages ktask.Stages) (ktask.Stages, ktask.Error) {
+	if len(stages) > 0 {
+		return stages, nil
+	}
+	declared, errs := parser.DeclaredStages(content)
 	if errs != nil {
 		return nil, ktask.NewParserErrors(errs)
 	}
-	return records, nil
+	return declared, nil
+}
+
+// parseData parses the content with the stages, which must have been resolved
+// by fileStages. The document keeps the original text, so the records can be
+// written back with minimal changes.
+func parseData(content string, stages ktask.Stages) ([]ktask.Record, *parser.Document, ktask.Error) {
+	records, blocks, errs := parser.NewSerialParserWithStages(stages).Parse(content)
+	if errs != nil {
+		return nil, nil, ktask.NewParserErrors(errs)
+	}
+	// the document must be created before arranging modifies the records
+	doc := parser.NewDocument(records, blocks)
+	return stages.Arrange(withoutCommentRecord(records)), doc, nil
 }
 
-func writeData(destination string, data []ktask.Record) error {
-	var err error
-	lock := destination + ".lock"
+// withoutCommentRecord drops the record which holds the comments of a file
+// without any stage. The document keeps them, so they are written back.
+func withoutCommentRecord(rs []ktask.Record) []ktask.Record {
+	return slices.DeleteFunc(rs, parser.IsCommentRecord)
+}
 
-	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
-	lines := parser.SerialiseRecords(ser, data...)
+// taskFile remembers the state of a file when it was read, to detect and
+// merge modifications made by others (e.g. an editor which doesn't care about
+// the lock) before writing it back.
+type taskFile struct {
+	path   string
+	stages ktask.Stages
+	// lenient files are read even if they contain errors, see parse
+	lenient bool
+	// problems are the errors found when the file was read leniently
+	problems []txt.Error
+	hash     [sha256.Size]byte
+	// base are the records as last read/written, they are the common
+	// ancestor when merging
+	base []ktask.Record
+	doc  *parser.Document
+	// backedUp is set once the file was backed up, which is done only once
+	// per lock, so that autosaving doesn't rotate all backups away
+	backedUp bool
+}
 
-	content := strings.Builder{}
-	for _, l := range lines {
-		content.WriteString(l.Text)
-		content.WriteRune('\n')
+// parse parses the content of the file. If the file is read leniently, lines
+// which can't be parsed are quarantined and remembered as problems instead of
+// failing, they are written back unchanged.
+func (f *taskFile) parse(content string) ([]ktask.Record, *parser.Document, ktask.Error) {
+	if !f.lenient {
+		return parseData(content, f.stages)
+	}
+	records, blocks, errs := parser.ParseLenient(content, f.stages)
+	if records == nil && errs != nil {
+		return nil, nil, ktask.NewParserErrors(errs)
 	}
+	f.problems = errs
+	// the document must be created before arranging modifies the records
+	doc := parser.NewDocument(records, blocks)
+	return f.stages.Arrange(withoutCommentRecord(records)), doc, nil
+}
 
-	if exists(destination) {
-		err = os.Rename(destination, destination+".bak")
-		if err != nil {
-			return fmt.Errorf("making backup before writing failed: %w", err)
+// readData locks the file, so it can be written back later on with
+// writeData, and reads and parses it. The lock is released if reading fails.
+func readData(source string, stages ktask.Stages, lenient bool) ([]ktask.Record, *taskFile, ktask.Error) {
+	if errK := acquireLock(source); errK != nil {
+		return nil, nil, errK
+	}
+	content, err := os.ReadFile(source)
+	if err != nil {
+		releaseLock(source)
+		return nil, nil, ktask.NewErrorWithCode(
+			ktask.NO_INPUT_ERROR,
+			"Error reading file",
+			"Location: "+source,
+			err,
+		)
+	}
+	stages, errK := fileStages(string(content), stages)
+	if errK != nil {
+		releaseLock(source)
+		return nil, nil, errK
+	}
+	file := &taskFile{path: source, stages: stages, lenient: lenient, hash: sha256.Sum256(content)}
+	records, doc, errK := file.parse(string(content))
+	if errK != nil {
+		releaseLock(source)
+		return nil, nil, errK
+	}
+	// parse again, as the records are modified in place
+	file.base, _, _ = file.parse(string(content))
+	file.doc = doc
+	return records, file, nil
+}
+
+// filePath returns the file to work on, falling back to the default task
+// file if none was specified.
+func filePath(file string) string {
+	if file == "" {
+		return filepath.Join(setupPath(), "tasks.ktask")
+	}
+	return file
+}
+
+// tagFilter returns a predicate which accepts entries having at least one of
+// the tags (or any tag, if tags is empty) and none of the noTags.
+func tagFilter(tags []string, noTags []string) func(e *ktask.Entry) bool {
+	return func(e *ktask.Entry) bool {
+		return (slices.ContainsFunc(tags, func(s string) bool {
+			t, _ := ktask.NewTagFromString(s)
+			return e.Name().Tags().Contains(t)
+		}) || len(tags) == 0) && !slices.ContainsFunc(noTags, func(s string) bool {
+			t, _ := ktask.NewTagFromString(s)
+			return e.Name().Tags().Contains(t)
+		})
+	}
+}
+
+// writeData writes the records to the file atomically. The content it had
+// when it was read is backed up before the first write. The lock is kept, so
+// the file can be written multiple times, use releaseLock when done.
+//
+// If the file was modified since it was read, the modifications are merged
+// with the records. In that case the merged records are returned together
+// with the conflicts which occurred, otherwise nil is returned.
+func writeData(file *taskFile, data []ktask.Record, backups int) ([]ktask.Record, []ktask.Conflict, error) {
+	var merged []ktask.Record
+	var conflicts []ktask.Conflict
+	current, err := os.ReadFile(file.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("checking for modifications failed: %w", err)
+	}
+	if err == nil && sha256.Sum256(current) != file.hash {
+		theirs, doc, errK := file.parse(string(current))
+		if errK != nil {
+			return nil, nil, fmt.Errorf("the file was modified meanwhile and can't be parsed anymore, fix it to save your changes: %w", errK)
 		}
+		merged, conflicts = ktask.MergeRecords(file.base, data, theirs)
+		// keep the formatting of the modified file
+		file.doc = doc
+		merged = file.stages.Arrange(merged)
+		data = merged
 	}
 
-	err = os.WriteFile(destination, []byte(content.String()), 0777)
+	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	// declared stages which are empty are shown anyway, they are only written
+	// if the file already contained them
+	written := slices.DeleteFunc(slices.Clone(data), func(r ktask.Record) bool {
+		return len(r.Entries()) == 0 && len(r.Comments()) == 0 && len(r.TrailingComments()) == 0 &&
+			file.stages.Contains(r.Stage()) && !file.doc.Contains(r.Stage())
+	})
+	content := file.doc.Serialise(ser, written...)
+	// make sure the file reads back as written, e.g. a line break in imported
+	// text would otherwise break it or turn the rest into another stage
+	base, doc, errK := file.parse(content)
+	if errK != nil {
+		return nil, nil, fmt.Errorf("the result can't be read back, the file is left untouched: %w", errK)
+	}
+	if !sameShape(base, data) {
+		return nil, nil, errors.New("the result can't be read back as written, the file is left untouched")
+	}
+
+	if !file.backedUp {
+		if err := backupFile(file.path, backups); err != nil {
+			return nil, nil, fmt.Errorf("making backup before writing failed: %w", err)
+		}
+		file.backedUp = true
+	}
+	if err := writeFileAtomic(file.path, []byte(content), 0o644); err != nil {
+		return nil, nil, fmt.Errorf("writing output file failed, the original file is left untouched: %w", err)
+	}
+	file.hash = sha256.Sum256([]byte(content))
+	file.base, file.doc = base, doc
+	return merged, conflicts, nil
+}
+
+// sameShape checks whether the records have the same stages with the same
+// number of entries, each with the same number of lines in its name and notes.
+func sameShape(a, b []ktask.Record) bool {
+	return slices.EqualFunc(a, b, func(ra, rb ktask.Record) bool {
+		return ra.Stage() == rb.Stage() && slices.EqualFunc(ra.Entries(), rb.Entries(), func(ea, eb ktask.Entry) bool {
+			return len(ea.Name()) == len(eb.Name()) && len(ea.Notes()) == len(eb.Notes())
+		})
+	})
+}
+
+// reloadData checks whether the file was modified since it was last read or
+// written. If so, the modifications are merged with the records and the
+// merged records are returned together with the conflicts which occurred,
+// otherwise nil is returned. The file itself is not written.
+func reloadData(file *taskFile, data []ktask.Record) ([]ktask.Record, []ktask.Conflict, error) {
+	current, err := os.ReadFile(file.path)
 	if err != nil {
-		return fmt.Errorf("writing output file failed. If needed you can find a backup of the original file located at the same place suffixed with .bak\n%w", err)
+		return nil, nil, err
 	}
-	return os.Remove(lock)
+	hash := sha256.Sum256(current)
+	if hash == file.hash {
+		return nil, nil, nil
+	}
+	theirs, doc, errK := file.parse(string(current))
+	if errK != nil {
+		return nil, nil, errors.New("the file was modified and can't be parsed anymore")
+	}
+	merged, conflicts := ktask.MergeRecords(file.base, data, theirs)
+	file.hash = hash
+	file.base, _, _ = file.parse(string(current))
+	file.doc = doc
+	return file.stages.Arrange(merged), conflicts, nil
+}
+
+// describeConflicts summarises the conflicts of a merge.
+func describeConflicts(conflicts []ktask.Conflict) []string {
+	var ret []string
+	for _, c := range conflicts {
+		ret = append(ret, fmt.Sprintf("conflict: %q %s", c.Entry.Title(), c.Reason))
+	}
+	return ret
+}
+
+// usageError reports a mistake in the arguments given on the command line,
+// such as a reference to an entry which doesn't exist.
+func usageError(details string, format string, a ...any) ktask.Error {
+	return ktask.NewErrorWithCode(ktask.LOGICAL_ERROR, fmt.Sprintf(format, a...), details, nil)
+}
+
+// describeProblems summarises the problems found when reading a file
+// leniently.
+func describeProblems(problems []txt.Error) []string {
+	var ret []string
+	for _, e := range problems {
+		ret = append(ret, fmt.Sprintf("line %d: %s", e.LineNumber(), e.Title()))
+	}
+	return ret
 }
 
 type rootCmd struct {
-	Kanban *argKanban `arg:"subcommand:kanban"`
+	Stages  []string    `arg:"--stages,env:KTASK_STAGES" help:"declare the stages of the board in the order they should be shown, other stages are rejected; overrides the stages declared in the file"`
+	Backups int         `arg:"--backups,env:KTASK_BACKUPS" default:"5" help:"number of backups to keep when writing a file"`
+	Kanban  *argKanban  `arg:"subcommand:kanban"`
+	List    *argList    `arg:"subcommand:list"`
+	Add     *argAdd     `arg:"subcommand:add"`
+	Move    *argMove    `arg:"subcommand:move"`
+	Edit    *argEdit    `arg:"subcommand:edit"`
+	Rm      *argRm      `arg:"subcommand:rm"`
+	Restore *argRestore `arg:"subcommand:restore"`
+	Fmt     *argFmt     `arg:"subcommand:fmt"`
+	Check   *argCheck   `arg:"subcommand:check"`
+	Repair  *argRepair  `arg:"subcommand:repair"`
+	Export  *argExport  `arg:"subcommand:export"`
+	Import  *argImport  `arg:"subcommand:import"`
+}
+
+// config holds the options which apply to all subcommands.
+type config struct {
+	stages  ktask.Stages
+	backups int
 }
 
 type argKanban struct {
-	File   string   `arg:"positional" help:"specify the file that should be read from / written to"`
-	Tags   []string `arg:"--tags,-t,separate" help:"if set, only entries with this/these tags will be shown, may be specified multiple times"`
-	NoTags []string `arg:"--no-tags,-T,separate" help:"if set, entries with this/these tags will NOT be shown, may be specified multiple times"`
+	File     string   `arg:"positional" help:"specify the file that should be read from / written to"`
+	Tags     []string `arg:"--tags,-t,separate" help:"if set, only entries with this/these tags will be shown, may be specified multiple times"`
+	NoTags   []string `arg:"--no-tags,-T,separate" help:"if set, entries with this/these tags will NOT be shown, may be specified multiple times"`
+	Autosave autosave `arg:"--autosave,env:KTASK_AUTOSAVE" help:"save automatically, either after every change (change) or periodically (e.g. 30s)"`
+	Fix      bool     `arg:"--fix" help:"offer to repair the file if it can't be read"`
+	Lenient  bool     `arg:"--lenient,env:KTASK_LENIENT" help:"open the board even if the file contains errors, the affected lines are kept as they are"`
+}
+
+// autosave is a command line argument which is either "change" or a
+// duration like "5m".
+type autosave struct {
+	onChange bool
+	interval time.Duration
+}
+
+func (a *autosave) UnmarshalText(b []byte) error {
+	switch s := string(b); s {
+	case "", "off":
+		*a = autosave{}
+	case "change":
+		*a = autosave{onChange: true}
+	default:
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid autosave %q, expected change or a duration like 30s", s)
+		}
+		*a = autosave{interval: d}
+	}
+	return nil
 }
 
 func main() {
 	var args rootCmd
-	var err error
-	var errK ktask.Error
-	arg.MustParse(&args)
+	argParser := arg.MustParse(&args)
+
+	stages, err := ktask.NewStages(args.Stages...)
+	if err != nil {
+		argParser.Fail(err.Error())
+	}
+	if args.Backups < 0 {
+		argParser.Fail("the number of backups must not be negative")
+	}
+	cfg := config{stages: stages, backups: args.Backups}
+
+	// make sure no lock is left behind, neither on panics nor on signals
+	defer releaseLocks()
+	stopSignals := releaseOnSignal(os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer func() { stopSignals() }()
 
 	switch {
 	case args.Kanban != nil:
-		var data []ktask.Record
-		path := args.Kanban.File
-		if path == "" {
-			path = filepath.Join(setupPath(), "tasks.ktask")
+		path := filePath(args.Kanban.File)
+		if args.Kanban.Fix {
+			if err = fixFile(path, cfg); err != nil {
+				break
+			}
 		}
-		data, errK = readData(path)
+		data, file, errK := readData(path, cfg.stages, args.Kanban.Lenient)
 		if errK != nil {
-			switch errK := errK.(type) {
-			case ktask.ParserErrors:
-				panic(ktask.PrettifyParsingError(errK, tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR)))
-			case ktask.Error:
-				panic(ktask.PrettifyAppError(errK, false))
-			default:
-				if err != nil {
-					panic(err)
-				}
-			}
+			err = errK
+			break
 		}
 
-		var data_shown []ktask.Record
-		var data_hidden []ktask.Record
-		if len(args.Kanban.Tags) == 0 && len(args.Kanban.NoTags) == 0 {
-			data_shown = data
-		} else {
+		// split returns the records shown on the board and the ones hidden by
+		// the filters
+		split := func(data []ktask.Record) ([]ktask.Record, []ktask.Record) {
+			if len(args.Kanban.Tags) == 0 && len(args.Kanban.NoTags) == 0 {
+				return data, nil
+			}
+			var shown, hidden []ktask.Record
 			for _, i := range data {
-				r1, r2 := i.SplitOnFunc(func(e *ktask.Entry) bool {
-					return (slices.ContainsFunc(args.Kanban.Tags, func(s string) bool {
-						t, _ := ktask.NewTagFromString(s)
-						return e.Name().Tags().Contains(t)
-					}) || len(args.Kanban.Tags) == 0) && !slices.ContainsFunc(args.Kanban.NoTags, func(s string) bool {
-						t, _ := ktask.NewTagFromString(s)
-						return e.Name().Tags().Contains(t)
-					})
-				})
-				data_shown = append(data_shown, r1)
-				data_hidden = append(data_hidden, r2)
+				r1, r2 := i.SplitOnFunc(tagFilter(args.Kanban.Tags, args.Kanban.NoTags))
+				shown = append(shown, r1)
+				hidden = append(hidden, r2)
 			}
+			return shown, hidden
 		}
+		data_shown, data_hidden := split(data)
 
 		var cols []kanban.Column
 		for i, r := range data_shown {
 			cols = append(cols, kanban.NewColumnFromRecord(r, i == 0))
 		}
 		board := kanban.NewDefaultBoard(cols)
+		// withHidden merges the hidden entries back into the shown ones before
+		// writing or merging, the result is split again afterwards
+		withHidden := func(fn func(file *taskFile, data []ktask.Record) ([]ktask.Record, []ktask.Conflict, error)) kanban.SaveFunc {
+			return func(shown []ktask.Record) (kanban.Saved, error) {
+				var data []ktask.Record
+				for _, r := range shown {
+					for _, h := range data_hidden {
+						if h.Stage() == r.Stage() {
+							r.Merge(h)
+						}
+					}
+					data = append(data, r)
+				}
+				merged, conflicts, err := fn(file, data)
+				if err != nil {
+					return kanban.Saved{}, err
+				}
+				// the problems are found anew whenever the file is read
+				saved := kanban.Saved{Warnings: describeProblems(file.problems)}
+				if merged != nil {
+					shown, data_hidden = split(merged)
+					saved.Records, saved.Conflicts = shown, describeConflicts(conflicts)
+				}
+				return saved, nil
+			}
+		}
+		save := withHidden(func(file *taskFile, data []ktask.Record) ([]ktask.Record, []ktask.Conflict, error) {
+			return writeData(file, data, cfg.backups)
+		})
+		board.SetSave(save, args.Kanban.Autosave.onChange, args.Kanban.Autosave.interval)
+		board.SetReload(kanban.ReloadFunc(withHidden(reloadData)))
+		board.SetWarnings(describeProblems(file.problems))
+
+		// the TUI quits itself on SIGINT/SIGTERM, so the changes are saved
+		stopSignals()
+		stopSignals = releaseOnSignal(syscall.SIGHUP)
 
 		var rboard tea.Model
 		p := tea.NewProgram(board)
@@ -187,19 +478,59 @@ func main() {
 			panic("tea returned something else than a board")
 		}
 
-		data = nil
-		for i, c := range nboard.Cols {
-			r := ktask.NewRecord(ktask.Stage(c.List.Title))
-			r.SetEntries(kanban.ItemsToTasks(c.List.Items()))
-			if i < len(data_hidden) {
-				r.Merge(data_hidden[i])
+		// the file is neither written nor backed up if nothing changed
+		if nboard.Dirty() {
+			saved, errS := save(nboard.Records())
+			if errS != nil {
+				err = errS
+				break
+			}
+			if saved.Records != nil {
+				fmt.Fprintln(os.Stderr, "merged the changes made to the file meanwhile")
+			}
+			for _, c := range saved.Conflicts {
+				fmt.Fprintln(os.Stderr, c)
 			}
-			data = append(data, r)
 		}
-
-		err = writeData(path, data)
-		if err != nil {
-			panic(err)
+		err = releaseLock(path)
+	case args.List != nil:
+		err = listData(args.List, cfg)
+	case args.Add != nil:
+		err = addEntry(args.Add, cfg)
+	case args.Move != nil:
+		err = moveEntry(args.Move, cfg)
+	case args.Edit != nil:
+		err = editEntry(args.Edit, cfg)
+	case args.Rm != nil:
+		err = removeEntry(args.Rm, cfg)
+	case args.Restore != nil:
+		err = restoreBackup(args.Restore, cfg)
+	case args.Fmt != nil:
+		err = formatData(args.Fmt, cfg)
+	case args.Check != nil:
+		err = checkData(args.Check, cfg)
+	case args.Repair != nil:
+		err = repairData(args.Repair, cfg)
+	case args.Export != nil:
+		err = exportData(args.Export, cfg)
+	case args.Import != nil:
+		err = importData(args.Import, cfg)
+	}
+	var errK ktask.Error
+	if errors.As(err, &errK) {
+		// report application errors properly and exit with their code
+		switch errK := errK.(type) {
+		case ktask.ParserErrors:
+			fmt.Fprintln(os.Stderr, ktask.PrettifyParsingError(errK, tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR)))
+		default:
+			fmt.Fprintln(os.Stderr, ktask.PrettifyAppError(errK, false))
 		}
+		releaseLocks()
+		os.Exit(int(errK.Code()))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		releaseLocks()
+		os.Exit(int(ktask.GENERAL_ERROR))
 	}
 }
@@ -1,12 +1,19 @@
 package ktask
 
 import (
+	"slices"
 	"strings"
 	"time"
 )
 
+// DueTagName is the name of the tag which holds the due date of an entry.
+const DueTagName = "due"
+
 type Entry struct {
+	id         string
 	name       Name
+	notes      []string
+	comments   []string
 	createdAt  time.Time
 	modifiedAt time.Time
 	index      int
@@ -21,6 +28,16 @@ func NewEntry(name Name, createdAt, modifiedAt time.Time, index int) Entry {
 	}
 }
 
+// ID returns the stable identifier of the entry, which is empty if the entry
+// doesn't have one.
+func (e *Entry) ID() string {
+	return e.id
+}
+
+func (e *Entry) SetID(id string) {
+	e.id = id
+}
+
 func (e *Entry) Index() int {
 	return e.index
 }
@@ -29,6 +46,37 @@ func (e *Entry) Name() Name {
 	return e.name
 }
 
+func (e *Entry) SetName(name Name) {
+	e.name = name
+}
+
+// Notes returns the free-form notes of the entry, one element per line.
+func (e *Entry) Notes() []string {
+	return e.notes
+}
+
+// SetNotes sets the notes of the entry. Blank lines are dropped, as they cannot
+// be represented in the file.
+func (e *Entry) SetNotes(notes []string) {
+	var ns []string
+	for _, n := range notes {
+		n = strings.TrimRight(n, " \t")
+		if n != "" {
+			ns = append(ns, n)
+		}
+	}
+	e.notes = ns
+}
+
+// Comments returns the comment lines preceding the entry in the file.
+func (e *Entry) Comments() []string {
+	return e.comments
+}
+
+func (e *Entry) SetComments(comments []string) {
+	e.comments = comments
+}
+
 func (e *Entry) CreatedAt() time.Time {
 	return e.createdAt
 }
@@ -37,6 +85,33 @@ func (e *Entry) ModifiedAt() time.Time {
 	return e.modifiedAt
 }
 
+// Due returns the due date of the entry and whether it has one. The due date
+// is given as tag in the name, e.g. `#due=2024-05-01`.
+func (e Entry) Due() (time.Time, bool) {
+	for _, t := range e.name.Tags().All() {
+		if t.Name() != DueTagName {
+			continue
+		}
+		due, err := time.Parse("2006-01-02", t.Value())
+		if err != nil {
+			continue
+		}
+		return due, true
+	}
+	return time.Time{}, false
+}
+
+// Equals checks whether both entries have the same content. The index is not
+// taken into account.
+func (e Entry) Equals(o Entry) bool {
+	return e.id == o.id &&
+		e.name.Equals(o.name) &&
+		slices.Equal(e.notes, o.notes) &&
+		slices.Equal(e.comments, o.comments) &&
+		e.createdAt.Equal(o.createdAt) &&
+		e.modifiedAt.Equal(o.modifiedAt)
+}
+
 func (e *Entry) SetModified() {
 	e.modifiedAt = time.Now()
 }
@@ -54,10 +129,19 @@ func (e Entry) FilterValue() string {
 		builder.WriteString(n)
 	}
 
+	for _, n := range e.notes {
+		builder.WriteRune(' ')
+		builder.WriteString(n)
+	}
+
 	for k := range e.name.Tags().ForLookup() {
 		builder.WriteString(k.ToString())
 	}
 
+	if p, ok := e.Priority(); ok {
+		builder.WriteString(" prio:" + p.ToString())
+	}
+
 	return builder.String()
 }
 
@@ -79,8 +163,15 @@ func (e Entry) Title() string {
 
 // define how this should be rendered with the default delegate
 func (e Entry) Description() string {
-	if e.name.Tags().IsEmpty() {
-		return ""
+	var parts []string
+	if project, ok := e.name.Project(); ok {
+		parts = append(parts, project.ToString())
+	}
+	if p, ok := e.Priority(); ok {
+		parts = append(parts, "prio "+p.ToString())
+	}
+	if due, ok := e.Due(); ok {
+		parts = append(parts, "due "+due.Format("2006-01-02"))
 	}
-	return e.name.Tags().original[0].ToString()
+	return strings.Join(parts, " · ")
 }
@@ -37,6 +37,12 @@ type Error interface {
 
 type Code int
 
+// Fixable is implemented by errors which can suggest how to resolve them.
+type Fixable interface {
+	// Fix returns a short suggestion how to resolve the error.
+	Fix() string
+}
+
 type parserErrors struct {
 	errors []txt.Error
 }
@@ -122,6 +128,12 @@ func PrettifyParsingError(err ParserErrors, styler tf.Styler) error {
 			styler.Props(tf.StyleProps{Color: tf.YELLOW}).Format("%s"),
 			Reflower.Reflow(e.Message(), []string{INDENT}),
 		) + "\n"
+		if f, ok := e.(Fixable); ok && f.Fix() != "" {
+			message += fmt.Sprintf(
+				styler.Props(tf.StyleProps{Color: tf.GREEN}).Format("%s"),
+				Reflower.Reflow("Fix: "+f.Fix(), []string{INDENT}),
+			) + "\n"
+		}
 	}
 	return errors.New(message)
 }
@@ -0,0 +1,73 @@
+package ktask
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// IdTagName is the name of the tag which holds the stable identifier of an
+// entry, e.g. `#id=3fa2c1`.
+const IdTagName = "id"
+
+// NewID returns a new random identifier which is not used by any entry of
+// the records yet.
+func NewID(rs ...Record) string {
+	for {
+		b := make([]byte, 3)
+		if _, err := rand.Read(b); err != nil {
+			panic(err) // This should never happen
+		}
+		id := hex.EncodeToString(b)
+		if FindByID(id, rs...) == nil {
+			return id
+		}
+	}
+}
+
+// FindByID returns the entry with the identifier or nil if there is none.
+func FindByID(id string, rs ...Record) *Entry {
+	if id == "" {
+		return nil
+	}
+	for _, r := range rs {
+		es := r.Entries()
+		for i := range es {
+			if es[i].ID() == id {
+				return &es[i]
+			}
+		}
+	}
+	return nil
+}
+
+// SplitID removes the id tag from the name and returns the identifier
+// separately. Only the first id tag per line is recognised and if there are
+// multiple lines with one, the first one wins.
+func SplitID(n Name) (Name, string) {
+	id := ""
+	ret := append(Name{}, n...)
+	for i, l := range ret {
+		for _, m := range HashTagPattern.FindAllStringIndex(l, -1) {
+			t, err := NewTagFromString(l[m[0]:m[1]])
+			if err != nil || t.Name() != IdTagName {
+				continue
+			}
+			if id == "" {
+				id = t.Value()
+			}
+			// Also remove one of the surrounding spaces.
+			start, end := m[0], m[1]
+			if start > 0 && l[start-1] == ' ' {
+				start--
+			} else if end < len(l) && l[end] == ' ' {
+				end++
+			}
+			ret[i] = l[:start] + l[end:]
+			break
+		}
+	}
+	if id == "" {
+		return n, ""
+	}
+	return ret, id
+}
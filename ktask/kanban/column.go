@@ -1,7 +1,10 @@
 package kanban
 
 import (
+	"fmt"
 	"ktask/ktask"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -19,6 +22,10 @@ type Column struct {
 	width  int
 	board  *Board
 	cnt    uint
+	// comments of the record, they can't be edited on the board but are
+	// kept when saving
+	comments []string
+	trailing []string
 }
 
 func (c *Column) Focus() {
@@ -37,7 +44,7 @@ func (c *Column) Focused() bool {
 
 // NewColumn creates a new column from a list.
 func NewColumn(l []list.Item, focus bool) Column {
-	defaultList := list.New(l, list.NewDefaultDelegate(), 0, 0)
+	defaultList := list.New(l, newEntryDelegate(), 0, 0)
 	defaultList.SetShowHelp(focus)
 	return Column{focus: focus, List: defaultList}
 }
@@ -46,10 +53,12 @@ func NewColumnFromRecord(r ktask.Record, focus bool) Column {
 	items := TasksToItems(r.Entries())
 	ret := Column{
 		focus: focus,
-		List:  list.New(items, list.NewDefaultDelegate(), 0, 0),
+		List:  list.New(items, newEntryDelegate(), 0, 0),
 	}
 	ret.List.SetShowHelp(focus)
 	ret.List.Title = string(r.Stage())
+	ret.comments = r.Comments()
+	ret.trailing = r.TrailingComments()
 
 	km := &ret.List.KeyMap
 	km.CloseFullHelp.Unbind()
@@ -69,7 +78,10 @@ func (c Column) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case ktask.Entry:
-		return c, c.Set(msg.Index(), msg)
+		// a new entry from the form, edited ones are sent as editMsg
+		msg.SetID(c.board.newID())
+		c.board.record(fmt.Sprintf("create %q", msg.Title()))
+		return c, c.Set(APPEND, msg)
 	case tea.WindowSizeMsg:
 		c.setSize(msg.Width, msg.Height)
 	case tea.KeyMsg:
@@ -78,23 +90,40 @@ func (c Column) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case key.Matches(msg, keys.Edit):
 				if len(c.List.VisibleItems()) != 0 {
 					item := c.List.SelectedItem().(ktask.Entry)
-					f := NewForm(item.Title(), item.Description(), item.CreatedAt(), time.Now())
-					f.title.SetValue(item.Title())
-					f.description.SetValue(item.Description())
-					f.index = c.List.Index()
+					f := NewForm(item.Title(), "notes", item.CreatedAt(), time.Now())
+					f.title.SetValue(strings.Join(item.Name().Lines(), " "))
+					f.notes.SetValue(strings.Join(item.Notes(), "\n"))
+					f.orig = &item
 					f.col = c
 					return f, tea.WindowSize()
 				}
+			case key.Matches(msg, keys.Detail):
+				if item, ok := c.List.SelectedItem().(ktask.Entry); ok {
+					d := NewDetail(item)
+					d.col = c
+					return d, tea.WindowSize()
+				}
 			case key.Matches(msg, keys.New):
 				f := newDefaultForm()
-				f.index = APPEND
 				f.col = c
 				return f, tea.WindowSize()
 			case key.Matches(msg, keys.Delete):
+				if item, ok := c.List.SelectedItem().(ktask.Entry); ok {
+					c.board.record(fmt.Sprintf("delete %q", item.Title()))
+				}
 				return c, c.DeleteCurrent()
+			case key.Matches(msg, keys.Sort):
+				c.board.record(fmt.Sprintf("sort %q", c.List.Title))
+				return c, c.SortBy(ktask.Chain(ktask.ByPriority, ktask.ByDue))
 			case key.Matches(msg, keys.Prev):
+				if item, ok := c.List.SelectedItem().(ktask.Entry); ok {
+					c.board.record(fmt.Sprintf("move %q to %q", item.Title(), c.board.neighbour(-1)))
+				}
 				return c, c.MoveToPrev()
 			case key.Matches(msg, keys.Next):
+				if item, ok := c.List.SelectedItem().(ktask.Entry); ok {
+					c.board.record(fmt.Sprintf("move %q to %q", item.Title(), c.board.neighbour(+1)))
+				}
 				return c, c.MoveToNext()
 			}
 		}
@@ -117,10 +146,36 @@ func (c *Column) DeleteCurrent() tea.Cmd {
 	return cmd
 }
 
-// Set adds an item to a column.
+// setEntries replaces the entries of the column. The selection is kept on the
+// same entry if it still exists.
+func (c *Column) setEntries(es []ktask.Entry) tea.Cmd {
+	selected, index := "", c.List.Index()
+	if item, ok := c.List.SelectedItem().(ktask.Entry); ok {
+		selected = item.ID()
+	}
+	cmd := c.List.SetItems(TasksToItems(es))
+	if j := c.indexOf(selected); j >= 0 {
+		index = j
+	}
+	c.List.Select(min(index, max(len(es)-1, 0)))
+	return cmd
+}
+
+// SortBy sorts the items of the column. The order of equal items is kept.
+func (c *Column) SortBy(cmp func(a, b ktask.Entry) int) tea.Cmd {
+	tasks := ItemsToTasks(c.List.Items())
+	slices.SortStableFunc(tasks, cmp)
+	return c.List.SetItems(TasksToItems(tasks))
+}
+
+// Set adds an item to a column. If an item with the same identifier already
+// exists, it is replaced, otherwise i is the position of the item to replace.
 func (c *Column) Set(i int, item list.Item) tea.Cmd {
 	itemEntry := item.(ktask.Entry)
 	itemEntry.SetModified()
+	if j := c.indexOf(itemEntry.ID()); j >= 0 {
+		i = j
+	}
 
 	if i != APPEND {
 		return c.List.SetItem(i, itemEntry)
@@ -128,6 +183,19 @@ func (c *Column) Set(i int, item list.Item) tea.Cmd {
 	return c.List.InsertItem(APPEND, itemEntry)
 }
 
+// indexOf returns the position of the item with the identifier or -1.
+func (c *Column) indexOf(id string) int {
+	if id == "" {
+		return -1
+	}
+	for i, item := range c.List.Items() {
+		if e := item.(ktask.Entry); e.ID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
 func (c *Column) setSize(width, height int) {
 	s := c.getStyle()
 	hb, vb := s.GetHorizontalBorderSize(), s.GetVerticalBorderSize()
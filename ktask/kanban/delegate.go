@@ -0,0 +1,68 @@
+package kanban
+
+import (
+	"io"
+	"ktask/ktask"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	overdueColor  = lipgloss.Color("9")
+	dueTodayColor = lipgloss.Color("11")
+
+	// prioColors are the colors of the titles of entries with priority A, B
+	// and C. Lower priorities are only shown in bold.
+	prioColors = []lipgloss.Color{"205", "214", "39"}
+)
+
+// entryDelegate renders entries like the default delegate, but highlights
+// entries with a priority as well as entries which are overdue or due today.
+type entryDelegate struct {
+	list.DefaultDelegate
+}
+
+func newEntryDelegate() entryDelegate {
+	return entryDelegate{list.NewDefaultDelegate()}
+}
+
+// Render prints an item.
+func (d entryDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	// d is a copy, so adjusting the styles only affects this item
+	if e, ok := item.(ktask.Entry); ok {
+		s := &d.Styles
+		if color, ok := dueColor(e, time.Now()); ok {
+			s.NormalTitle = s.NormalTitle.Foreground(color)
+			s.SelectedTitle = s.SelectedTitle.Foreground(color)
+			s.NormalDesc = s.NormalDesc.Foreground(color)
+			s.SelectedDesc = s.SelectedDesc.Foreground(color)
+		}
+		if p, ok := e.Priority(); ok {
+			s.NormalTitle = s.NormalTitle.Bold(true)
+			s.SelectedTitle = s.SelectedTitle.Bold(true)
+			if i := int(p - 'A'); i < len(prioColors) {
+				s.NormalTitle = s.NormalTitle.Foreground(prioColors[i])
+				s.SelectedTitle = s.SelectedTitle.Foreground(prioColors[i])
+			}
+		}
+	}
+	d.DefaultDelegate.Render(w, m, index, item)
+}
+
+// dueColor returns the color the entry should be highlighted with, if it is
+// overdue or due today.
+func dueColor(e ktask.Entry, now time.Time) (lipgloss.Color, bool) {
+	due, ok := e.Due()
+	if !ok {
+		return "", false
+	}
+	switch d, today := due.Format("2006-01-02"), now.Format("2006-01-02"); {
+	case d < today:
+		return overdueColor, true
+	case d == today:
+		return dueTodayColor, true
+	}
+	return "", false
+}
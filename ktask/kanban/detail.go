@@ -0,0 +1,112 @@
+package kanban
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Detail shows the complete content of an entry: its name, all tags, the
+// dates and the notes.
+type Detail struct {
+	help        help.Model
+	entry       ktask.Entry
+	col         Column
+	totalWidth  int
+	totalHeight int
+}
+
+func NewDetail(entry ktask.Entry) *Detail {
+	return &Detail{
+		help:  help.New(),
+		entry: entry,
+	}
+}
+
+func (d Detail) Init() tea.Cmd {
+	return nil
+}
+
+func (d Detail) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.totalWidth, d.totalHeight = msg.Width, msg.Height
+	case saveMsg, tickMsg, watchMsg:
+		// keep saving/reloading while the details are shown
+		_, cmd := d.col.board.Update(msg)
+		return d, cmd
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back), key.Matches(msg, keys.Detail):
+			return d.col.board, nil
+		}
+	}
+	return d, nil
+}
+
+func (d Detail) View() string {
+	width := min(80, d.totalWidth-4)
+	text := lipgloss.NewStyle().Width(width)
+	faint := lipgloss.NewStyle().Faint(true)
+
+	notes := strings.Join(d.entry.Notes(), "\n")
+	if notes == "" {
+		notes = faint.Render("no notes")
+	}
+	tags := strings.Join(d.entry.Name().Tags().ToStrings(), " ")
+	if tags == "" {
+		tags = faint.Render("no tags")
+	}
+	fields := [][2]string{
+		{"created", d.entry.CreatedAt().Format("2006-01-02")},
+		{"modified", d.entry.ModifiedAt().Format("2006-01-02")},
+		{"age", fmt.Sprintf("%d days", ageInDays(d.entry.CreatedAt(), time.Now()))},
+	}
+	if due, ok := d.entry.Due(); ok {
+		fields = append(fields, [2]string{"due", due.Format("2006-01-02")})
+	}
+	if p, ok := d.entry.Priority(); ok {
+		fields = append(fields, [2]string{"priority", p.ToString()})
+	}
+	if id := d.entry.ID(); id != "" {
+		fields = append(fields, [2]string{"id", id})
+	}
+	var table []string
+	for _, f := range fields {
+		table = append(table, faint.Render(fmt.Sprintf("%-9s", f[0]))+f[1])
+	}
+
+	return lipgloss.Place(
+		d.totalWidth, d.totalHeight, 0.5, 0.5,
+		lipgloss.NewStyle().
+			Padding(0, 1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("62")).
+			Render(
+				lipgloss.JoinVertical(
+					lipgloss.Left,
+					text.Bold(true).Render(strings.Join(d.entry.Name().Lines(), "\n")),
+					text.Render(tags),
+					"",
+					strings.Join(table, "\n"),
+					"",
+					text.Render(notes),
+					"",
+					d.help.ShortHelpView([]key.Binding{keys.Back}),
+				),
+			),
+	)
+}
+
+// ageInDays returns the number of (calendar) days since the date.
+func ageInDays(since time.Time, now time.Time) int {
+	from := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
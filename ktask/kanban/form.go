@@ -14,36 +14,43 @@ import (
 )
 
 type Form struct {
-	help        help.Model
-	title       textinput.Model
-	description textarea.Model
-	createdAt   time.Time
-	modifiedAt  time.Time
-	col         Column
-	index       int
+	help       help.Model
+	title      textinput.Model
+	notes      textarea.Model
+	createdAt  time.Time
+	modifiedAt time.Time
+	col        Column
+	// orig is the entry being edited, nil if a new one is created
+	orig        *ktask.Entry
 	totalWidth  int
 	totalHeight int
 }
 
 func newDefaultForm() *Form {
-	return NewForm("task name", "", time.Now(), time.Now())
+	return NewForm("task name #project", "notes", time.Now(), time.Now())
 }
 
-func NewForm(title, description string, createdAt time.Time, modifiedAt time.Time) *Form {
+// NewForm creates a form to create or edit an entry. The title and notes are
+// used as placeholders.
+func NewForm(title, notes string, createdAt time.Time, modifiedAt time.Time) *Form {
 	form := Form{
-		help:        help.New(),
-		title:       textinput.New(),
-		description: textarea.New(),
-		createdAt:   createdAt,
-		modifiedAt:  modifiedAt,
+		help:       help.New(),
+		title:      textinput.New(),
+		notes:      textarea.New(),
+		createdAt:  createdAt,
+		modifiedAt: modifiedAt,
 	}
 
 	form.title.Width = 10
-	form.description.SetWidth(10)
-	form.description.SetHeight(10)
+	form.notes.SetWidth(10)
+	form.notes.SetHeight(10)
+
+	// Enter submits the form, so new lines in the notes need another key.
+	form.notes.KeyMap.InsertNewline.SetKeys("alt+enter", "ctrl+j")
+	form.notes.KeyMap.InsertNewline.SetHelp("alt+enter", "new line")
 
 	form.title.Placeholder = title
-	form.description.Placeholder = description
+	form.notes.Placeholder = notes
 	form.title.Focus()
 	return &form
 }
@@ -60,6 +67,10 @@ func (f Form) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		f.col.List.Index()
 	case tea.WindowSizeMsg:
 		f.setSize(msg.Width, msg.Height)
+	case saveMsg, tickMsg, watchMsg:
+		// keep saving/reloading while the form is shown
+		_, cmd = f.col.board.Update(msg)
+		return f, cmd
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, keys.Back):
@@ -67,21 +78,19 @@ func (f Form) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Enter):
 			if f.title.Focused() {
 				f.title.Blur()
-				f.description.Focus()
+				f.notes.Focus()
 				return f, textarea.Blink
 			}
 			// Return the completed form as a message.
 			if f.title.Value() != "" {
-				tag := f.description.Value()
-				sep := ""
-				if tag != "" {
-					if !strings.HasPrefix(tag, "#") {
-						tag = "#" + tag
-					}
-					sep = " "
+				item := ktask.NewEntry(ktask.Name{f.title.Value()}, f.createdAt, f.modifiedAt, APPEND)
+				item.SetNotes(strings.Split(f.notes.Value(), "\n"))
+				if f.orig == nil {
+					return f.col.board.Update(item)
 				}
-				item := ktask.NewEntry(ktask.Name(strings.Split(tag+sep+f.title.Value(), "\n")), f.createdAt, f.modifiedAt, f.index)
-				return f.col.board.Update(item)
+				item.SetID(f.orig.ID())
+				item.SetComments(f.orig.Comments())
+				return f.col.board.Update(editMsg{*f.orig, item})
 			}
 			return f.col.board, nil
 		}
@@ -90,10 +99,16 @@ func (f Form) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		f.title, cmd = f.title.Update(msg)
 		return f, cmd
 	}
-	f.description, cmd = f.description.Update(msg)
+	f.notes, cmd = f.notes.Update(msg)
 	return f, cmd
 }
 
+// editMsg replaces the original entry, wherever it is on the board by now.
+type editMsg struct {
+	orig  ktask.Entry
+	entry ktask.Entry
+}
+
 func (f Form) View() string {
 	return lipgloss.Place(
 		f.totalWidth, f.totalHeight, 0.5, 0.75,
@@ -106,8 +121,8 @@ func (f Form) View() string {
 					lipgloss.Left,
 					"Create a new task",
 					f.title.View(),
-					f.description.View(),
-					f.help.View(keys),
+					f.notes.View(),
+					f.help.ShortHelpView([]key.Binding{keys.Enter, f.notes.KeyMap.InsertNewline, keys.Back}),
 				),
 			),
 	)
@@ -116,6 +131,6 @@ func (f Form) View() string {
 func (f *Form) setSize(width, height int) {
 	f.totalWidth, f.totalHeight = width, height
 	f.title.Width = 80
-	f.description.SetWidth(80)
-	f.description.SetHeight(4)
+	f.notes.SetWidth(80)
+	f.notes.SetHeight(4)
 }
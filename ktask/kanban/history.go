@@ -0,0 +1,114 @@
+package kanban
+
+import (
+	"ktask/ktask"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// snapshot is the state of all columns of the board together with the
+// description of the operation which followed it.
+type snapshot struct {
+	description string
+	items       [][]list.Item
+}
+
+// history holds the states of the board to undo/redo operations.
+type history struct {
+	undo []snapshot
+	redo []snapshot
+}
+
+// snapshot captures the current items of all columns.
+func (m *Board) snapshot(description string) snapshot {
+	s := snapshot{description: description}
+	for _, c := range m.Cols {
+		// the list modifies its items in place, so a copy is needed
+		s.items = append(s.items, slices.Clone(c.List.Items()))
+	}
+	return s
+}
+
+// record has to be called before an operation modifies the board, so the
+// operation can be undone later on.
+func (m *Board) record(description string) {
+	m.history.undo = append(m.history.undo, m.snapshot(description))
+	m.history.redo = nil
+	m.status = ""
+	m.dirty = true
+}
+
+// restore sets the items of all columns to the state of the snapshot.
+func (m *Board) restore(s snapshot) tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range m.Cols {
+		cmds = append(cmds, m.Cols[i].List.SetItems(s.items[i]))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Undo reverts the last operation.
+func (m *Board) Undo() tea.Cmd {
+	if len(m.history.undo) == 0 {
+		m.status = "nothing to undo"
+		return nil
+	}
+	s := m.history.undo[len(m.history.undo)-1]
+	m.history.undo = m.history.undo[:len(m.history.undo)-1]
+	m.history.redo = append(m.history.redo, m.snapshot(s.description))
+	m.status = "undid " + s.description
+	m.dirty = true
+	return m.restore(s)
+}
+
+// Redo applies the last undone operation again.
+func (m *Board) Redo() tea.Cmd {
+	if len(m.history.redo) == 0 {
+		m.status = "nothing to redo"
+		return nil
+	}
+	s := m.history.redo[len(m.history.redo)-1]
+	m.history.redo = m.history.redo[:len(m.history.redo)-1]
+	m.history.undo = append(m.history.undo, m.snapshot(s.description))
+	m.status = "redid " + s.description
+	m.dirty = true
+	return m.restore(s)
+}
+
+// rebaseHistory applies the modifications made by someone else, which turned
+// the board from before into after, to the snapshots, so the operations can
+// still be undone/redone without reverting the modifications. Snapshots which
+// conflict with the modifications can't be restored anymore, so they are
+// dropped together with the ones behind them.
+func (m *Board) rebaseHistory(before, after []ktask.Record) {
+	rebase := func(ss []snapshot) []snapshot {
+		for i := len(ss) - 1; i >= 0; i-- {
+			var rs []ktask.Record
+			for j, items := range ss[i].items {
+				r := ktask.NewRecord(ktask.Stage(m.Cols[j].List.Title))
+				r.SetEntries(ItemsToTasks(items))
+				rs = append(rs, r)
+			}
+			// the entries keep their order in the snapshot
+			merged, conflicts := ktask.MergeRecords(before, after, rs)
+			if len(conflicts) > 0 {
+				return slices.Clone(ss[i+1:])
+			}
+			ss[i].items = nil
+			for _, c := range m.Cols {
+				var es []ktask.Entry
+				for _, r := range merged {
+					if string(r.Stage()) == c.List.Title {
+						es = append(es, r.Entries()...)
+					}
+				}
+				ss[i].items = append(ss[i].items, TasksToItems(es))
+			}
+		}
+		return ss
+	}
+	m.history.undo = rebase(m.history.undo)
+	m.history.redo = rebase(m.history.redo)
+}
@@ -0,0 +1,108 @@
+package kanban
+
+import (
+	"testing"
+	"time"
+
+	"ktask/ktask"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBoard creates a board with a todo and a done column, the names are
+// the titles and identifiers of the entries.
+func newTestBoard(todo []string, done []string) *Board {
+	var cols []Column
+	for i, names := range [][]string{todo, done} {
+		cols = append(cols, NewColumnFromRecord(testRecord([]ktask.Stage{ktask.Todo, ktask.Done}[i], names...), i == 0))
+	}
+	return NewDefaultBoard(cols)
+}
+
+func testRecord(stage ktask.Stage, names ...string) ktask.Record {
+	r := ktask.NewRecord(stage)
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	for i, n := range names {
+		e := ktask.NewEntry(ktask.Name{n}, day, day, i)
+		e.SetID(n)
+		r.AppendEntry(e)
+	}
+	return r
+}
+
+// titles returns the titles of the entries of every column.
+func titles(m *Board) [][]string {
+	var ret [][]string
+	for _, r := range m.Records() {
+		ts := []string{}
+		for _, e := range r.Entries() {
+			ts = append(ts, e.Title())
+		}
+		ret = append(ret, ts)
+	}
+	return ret
+}
+
+func press(m *Board, k string) {
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+}
+
+func TestUndoRedo(t *testing.T) {
+	m := newTestBoard([]string{"aaaaaa", "bbbbbb"}, nil)
+	press(m, "d")
+	assert.Equal(t, [][]string{{"bbbbbb"}, {}}, titles(m))
+	assert.True(t, m.Dirty())
+
+	m.Undo()
+	assert.Equal(t, [][]string{{"aaaaaa", "bbbbbb"}, {}}, titles(m))
+	assert.Equal(t, `undid delete "aaaaaa"`, m.status)
+	m.Redo()
+	assert.Equal(t, [][]string{{"bbbbbb"}, {}}, titles(m))
+	assert.Equal(t, `redid delete "aaaaaa"`, m.status)
+
+	m.Redo()
+	assert.Equal(t, "nothing to redo", m.status)
+	m.Undo()
+	m.Undo()
+	assert.Equal(t, "nothing to undo", m.status)
+
+	// a new operation discards what was undone
+	press(m, "d")
+	m.Redo()
+	assert.Equal(t, "nothing to redo", m.status)
+}
+
+func TestUndoAfterReload(t *testing.T) {
+	m := newTestBoard([]string{"aaaaaa", "bbbbbb"}, nil)
+	press(m, "d")
+
+	// someone else added an entry and a stage
+	m.SetRecords([]ktask.Record{
+		testRecord(ktask.Todo, "bbbbbb", "cccccc"),
+		testRecord(ktask.Done),
+		testRecord("waiting", "dddddd"),
+	})
+	require.Equal(t, [][]string{{"bbbbbb", "cccccc"}, {}, {"dddddd"}}, titles(m))
+
+	// undoing keeps their modifications
+	m.Undo()
+	assert.Equal(t, [][]string{{"aaaaaa", "bbbbbb", "cccccc"}, {}, {"dddddd"}}, titles(m))
+	m.Redo()
+	assert.Equal(t, [][]string{{"bbbbbb", "cccccc"}, {}, {"dddddd"}}, titles(m))
+}
+
+func TestUndoAfterConflictingReload(t *testing.T) {
+	m := newTestBoard([]string{"aaaaaa", "bbbbbb"}, nil)
+	press(m, "d")
+
+	// someone else changed the deleted entry, so it was kept
+	changed := testRecord(ktask.Todo, "bbbbbb", "aaaaaa")
+	changed.Entries()[1].SetName(ktask.Name{"changed"})
+	m.SetRecords([]ktask.Record{changed, testRecord(ktask.Done)})
+
+	m.Undo()
+	assert.Equal(t, "nothing to undo", m.status)
+	assert.Equal(t, [][]string{{"bbbbbb", "changed"}, {}}, titles(m))
+}
@@ -15,7 +15,10 @@ func (k keyMap) FullHelp() [][]key.Binding {
 		{k.Up, k.Down},    // first column
 		{k.Left, k.Right}, // second column
 		{k.New, k.Delete}, // third column
-		{k.Edit},          // third column
+		{k.Edit, k.Sort},  // third column
+		{k.Detail},        // third column
+		{k.Undo, k.Redo},  // third column
+		{k.Save},          // third column
 		{k.Next, k.Prev},  // third column
 		{k.Help, k.Quit},  // fourth column
 	}
@@ -25,6 +28,11 @@ type keyMap struct {
 	New    key.Binding
 	Edit   key.Binding
 	Delete key.Binding
+	Sort   key.Binding
+	Detail key.Binding
+	Undo   key.Binding
+	Redo   key.Binding
+	Save   key.Binding
 	Up     key.Binding
 	Down   key.Binding
 	Right  key.Binding
@@ -51,6 +59,26 @@ var keys = keyMap{
 		key.WithKeys("d", "x"),
 		key.WithHelp("d/x", "delete"),
 	),
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sort by priority/due date"),
+	),
+	Detail: key.NewBinding(
+		key.WithKeys("v", " "),
+		key.WithHelp("v/space", "view details"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "redo"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "save"),
+	),
 	Up: key.NewBinding(
 		key.WithKeys("up", "k"),
 		key.WithHelp("↑/k", "move up"),
@@ -69,7 +97,7 @@ var keys = keyMap{
 	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
-		key.WithHelp("enter", "enter"),
+		key.WithHelp("enter", "confirm"),
 	),
 	Next: key.NewBinding(
 		key.WithKeys("enter"),
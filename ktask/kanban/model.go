@@ -1,6 +1,10 @@
 package kanban
 
 import (
+	"fmt"
+	"ktask/ktask"
+	"time"
+
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,12 +19,24 @@ type Board struct {
 	Focused  int
 	Cols     []Column
 	quitting bool
+	history  history
+	// status is a message about the last operation, shown below the board
+	status string
+	// warnings are problems of the file, shown above the board
+	warnings []string
+	// dirty is set if there are changes which are not saved yet
+	dirty            bool
+	save             SaveFunc
+	reload           ReloadFunc
+	autosaveOnChange bool
+	autosaveInterval time.Duration
 }
 
 type focus int
 
-// NewDefaultBoard creates a new kanban board with To Do, In Progress, and Done
-// columns.
+// NewDefaultBoard creates a new kanban board with the given columns. The
+// columns are shown in the order they are passed, usually the order of the
+// declared stages.
 func NewDefaultBoard(cols []Column) *Board {
 	help := help.New()
 	help.ShowAll = false
@@ -36,8 +52,49 @@ func NewDefaultBoard(cols []Column) *Board {
 	return b
 }
 
+// SetWarnings sets problems of the file which are shown above the board,
+// e.g. lines which couldn't be read.
+func (m *Board) SetWarnings(warnings []string) {
+	m.warnings = warnings
+}
+
+// updateWarnings replaces the warnings after the file was saved or reloaded.
+// The columns are resized if the height of the warnings changed.
+func (m *Board) updateWarnings(warnings []string) tea.Cmd {
+	changed := len(warnings) != len(m.warnings)
+	m.warnings = warnings
+	if !changed {
+		return nil
+	}
+	return tea.WindowSize()
+}
+
+// locate returns the column and position of the entry or -1 if it isn't on
+// the board.
+func (m *Board) locate(e ktask.Entry) (int, int) {
+	for ci, c := range m.Cols {
+		for i, item := range c.List.Items() {
+			if ktask.SameEntry(item.(ktask.Entry), e) {
+				return ci, i
+			}
+		}
+	}
+	return -1, -1
+}
+
+// newID returns an identifier which is not used by any entry on the board.
+func (m *Board) newID() string {
+	return ktask.NewID(m.Records()...)
+}
+
+// neighbour returns the title of the column next to the focused one in the
+// given direction.
+func (m *Board) neighbour(direction int) string {
+	return m.Cols[mod(m.Focused+direction, len(m.Cols))].List.Title
+}
+
 func (m *Board) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.tick(), m.watch())
 }
 
 func mod(a, b int) int {
@@ -45,12 +102,25 @@ func mod(a, b int) int {
 }
 
 func (m *Board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	res, cmd := m.update(msg)
+	if m.dirty && m.autosaveOnChange && res == m {
+		// save after the messages caused by the change (e.g. moving an entry)
+		// were handled
+		cmd = tea.Sequence(cmd, func() tea.Msg { return saveMsg{auto: true} })
+	}
+	return res, cmd
+}
+
+func (m *Board) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.help.Width = msg.Width - margin
-		msg.Height -= lipgloss.Height(m.help.View(keys))
+		msg.Height -= lipgloss.Height(m.help.View(keys)) + lipgloss.Height(m.statusView())
+		if len(m.warnings) > 0 {
+			msg.Height -= lipgloss.Height(m.warningView())
+		}
 		for i := 0; i < len(m.Cols); i++ {
 			var res tea.Model
 			res, cmd = m.Cols[i].Update(msg)
@@ -59,9 +129,26 @@ func (m *Board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.loaded = true
 		return m, tea.Batch(cmds...)
+	case saveMsg:
+		return m, m.saveNow(msg.auto)
+	case tickMsg:
+		return m, tea.Batch(m.saveNow(true), m.tick())
+	case watchMsg:
+		return m, tea.Batch(m.reloadNow(), m.watch())
+	case editMsg:
+		// the board might have been reloaded while the form was shown, so the
+		// entry is looked up again
+		ci, i := m.locate(msg.orig)
+		if ci < 0 {
+			// removed meanwhile, it is added again
+			ci, i = m.Focused, APPEND
+		}
+		m.record(fmt.Sprintf("edit %q", msg.entry.Title()))
+		return m, m.Cols[ci].Set(i, msg.entry)
 	case MoveMsg:
 		cmds = append(cmds, m.Cols[mod((m.Focused+msg.direction), len(m.Cols))].Set(APPEND, msg.item))
 	case tea.KeyMsg:
+		m.status = ""
 		if !m.Cols[m.Focused].List.SettingFilter() {
 			switch {
 			case key.Matches(msg, keys.Help):
@@ -71,6 +158,12 @@ func (m *Board) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case key.Matches(msg, keys.Quit):
 				m.quitting = true
 				return m, tea.Quit
+			case key.Matches(msg, keys.Save):
+				return m, m.saveNow(false)
+			case key.Matches(msg, keys.Undo):
+				return m, m.Undo()
+			case key.Matches(msg, keys.Redo):
+				return m, m.Redo()
 			case key.Matches(msg, keys.Left):
 				m.Cols[m.Focused].Blur()
 				m.Focused = mod((m.Focused - 1), len(m.Cols))
@@ -109,5 +202,25 @@ func (m *Board) View() string {
 		lipgloss.Left,
 		cs...,
 	)
-	return lipgloss.JoinVertical(lipgloss.Left, board, m.help.View(keys))
+	if len(m.warnings) > 0 {
+		board = lipgloss.JoinVertical(lipgloss.Left, m.warningView(), board)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, board, m.statusView(), m.help.View(keys))
+}
+
+func (m *Board) warningView() string {
+	text := fmt.Sprintf("%d problem(s) in the file, the affected lines are kept as they are:", len(m.warnings))
+	for _, w := range m.warnings {
+		text += "\n  " + w
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("3")).
+		Padding(0, 1).
+		Width(m.help.Width).
+		Render(text)
+}
+
+func (m *Board) statusView() string {
+	return lipgloss.NewStyle().Faint(true).Render(" " + m.status)
 }
@@ -0,0 +1,49 @@
+package kanban
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reloadInterval is how often the file is checked for modifications.
+const reloadInterval = time.Second
+
+// ReloadFunc checks whether the file was modified by someone else. If so, the
+// modifications are merged into the records shown on the board, see Saved.
+type ReloadFunc SaveFunc
+
+// watchMsg triggers checking the file for modifications.
+type watchMsg struct{}
+
+// SetReload configures how the board notices modifications of the file made
+// by someone else.
+func (m *Board) SetReload(reload ReloadFunc) {
+	m.reload = reload
+}
+
+func (m *Board) watch() tea.Cmd {
+	if m.reload == nil {
+		return nil
+	}
+	return tea.Tick(reloadInterval, func(time.Time) tea.Msg { return watchMsg{} })
+}
+
+// reloadNow shows the modifications made by someone else on the board.
+func (m *Board) reloadNow() tea.Cmd {
+	reloaded, err := m.reload(m.Records())
+	if err != nil {
+		m.status = "reloading failed: " + err.Error()
+		return nil
+	}
+	if reloaded.Records == nil {
+		return nil
+	}
+	m.status = "reloaded the file as it was modified"
+	if len(reloaded.Conflicts) > 0 {
+		m.status += fmt.Sprintf(", %d conflict(s): %s", len(reloaded.Conflicts), strings.Join(reloaded.Conflicts, "; "))
+	}
+	return tea.Batch(m.SetRecords(reloaded.Records), m.updateWarnings(reloaded.Warnings))
+}
@@ -0,0 +1,51 @@
+package kanban
+
+import (
+	"testing"
+
+	"ktask/ktask"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	m := newTestBoard([]string{"aaaaaa"}, nil)
+	var reloaded Saved
+	m.SetReload(func(rs []ktask.Record) (Saved, error) {
+		return reloaded, nil
+	})
+
+	// nothing happens if the file wasn't modified
+	m.Update(watchMsg{})
+	assert.Equal(t, [][]string{{"aaaaaa"}, {}}, titles(m))
+	assert.Equal(t, "", m.status)
+
+	reloaded = Saved{
+		Records:   []ktask.Record{testRecord(ktask.Todo), testRecord(ktask.Done, "aaaaaa")},
+		Conflicts: []string{"conflict: \"aaaaaa\" changed in both versions"},
+	}
+	m.Update(watchMsg{})
+	assert.Equal(t, [][]string{{}, {"aaaaaa"}}, titles(m))
+	assert.Equal(t, "reloaded the file as it was modified, 1 conflict(s): conflict: \"aaaaaa\" changed in both versions", m.status)
+}
+
+func TestEditAfterReload(t *testing.T) {
+	m := newTestBoard([]string{"aaaaaa", "bbbbbb"}, nil)
+	m.Cols[0].List.Select(1)
+	res, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	f, ok := res.(*Form)
+	require.True(t, ok)
+
+	// meanwhile someone else moved the entry being edited
+	m.SetRecords([]ktask.Record{testRecord(ktask.Todo, "cccccc", "aaaaaa"), testRecord(ktask.Done, "bbbbbb")})
+
+	f.title.SetValue("bbbbbb edited")
+	res, _ = f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	res, _ = res.(Form).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, m, res)
+	assert.Equal(t, [][]string{{"cccccc", "aaaaaa"}, {"bbbbbb edited"}}, titles(m))
+	edited := m.Cols[1].List.Items()[0].(ktask.Entry)
+	assert.Equal(t, "bbbbbb", edited.ID())
+}
@@ -0,0 +1,130 @@
+package kanban
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SaveFunc persists the records shown on the board. If the file was modified
+// by someone else meanwhile, the modifications are merged, see Saved.
+type SaveFunc func([]ktask.Record) (Saved, error)
+
+// Saved is the outcome of saving or reloading the board.
+type Saved struct {
+	// Records are the merged records to show if the file was modified by
+	// someone else, otherwise nil.
+	Records []ktask.Record
+	// Conflicts describe the conflicts of the merge.
+	Conflicts []string
+	// Warnings are the problems of the file as it is now, see SetWarnings.
+	Warnings []string
+}
+
+// saveMsg triggers saving the board. If auto is set, the board is only saved
+// if there are unsaved changes.
+type saveMsg struct {
+	auto bool
+}
+
+// tickMsg triggers the periodic autosave.
+type tickMsg struct{}
+
+// SetSave configures how the board is saved. If onChange is set, the board is
+// saved after every change, if interval is positive, unsaved changes are
+// saved periodically.
+func (m *Board) SetSave(save SaveFunc, onChange bool, interval time.Duration) {
+	m.save = save
+	m.autosaveOnChange = onChange
+	m.autosaveInterval = interval
+}
+
+// Records returns the entries of the board grouped by the columns.
+func (m *Board) Records() []ktask.Record {
+	var rs []ktask.Record
+	for _, c := range m.Cols {
+		r := ktask.NewRecord(ktask.Stage(c.List.Title))
+		r.SetEntries(ItemsToTasks(c.List.Items()))
+		r.SetComments(c.comments)
+		r.SetTrailingComments(c.trailing)
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+// Dirty reports whether the board has changes which are not saved yet.
+func (m *Board) Dirty() bool {
+	return m.dirty
+}
+
+func (m *Board) tick() tea.Cmd {
+	if m.save == nil || m.autosaveInterval <= 0 {
+		return nil
+	}
+	return tea.Tick(m.autosaveInterval, func(time.Time) tea.Msg { return tickMsg{} })
+}
+
+// saveNow writes the board using the configured SaveFunc.
+func (m *Board) saveNow(auto bool) tea.Cmd {
+	if m.save == nil || (auto && !m.dirty) {
+		return nil
+	}
+	saved, err := m.save(m.Records())
+	if err != nil {
+		m.status = "saving failed: " + err.Error()
+		return nil
+	}
+	m.dirty = false
+	if auto {
+		m.status = "autosaved"
+	} else {
+		m.status = "saved"
+	}
+	resize := m.updateWarnings(saved.Warnings)
+	if saved.Records == nil {
+		return resize
+	}
+	m.status += ", merged the changes made to the file meanwhile"
+	if len(saved.Conflicts) > 0 {
+		m.status += fmt.Sprintf(", %d conflict(s) (see #%s): %s", len(saved.Conflicts), ktask.ConflictTagName, strings.Join(saved.Conflicts, "; "))
+	}
+	return tea.Batch(m.SetRecords(saved.Records), resize)
+}
+
+// SetRecords replaces the entries shown on the board, e.g. after the file was
+// modified by someone else. The columns are matched by their stage and new
+// stages get a new column. The focus and the selected entries are kept where
+// possible. The undo history is rebased onto the new entries.
+func (m *Board) SetRecords(rs []ktask.Record) tea.Cmd {
+	before := m.Records()
+	var cmds []tea.Cmd
+	for i := range m.Cols {
+		var es []ktask.Entry
+		m.Cols[i].comments, m.Cols[i].trailing = nil, nil
+		for _, r := range rs {
+			if string(r.Stage()) == m.Cols[i].List.Title {
+				es = append(es, r.Entries()...)
+				m.Cols[i].comments = append(m.Cols[i].comments, r.Comments()...)
+				m.Cols[i].trailing = append(m.Cols[i].trailing, r.TrailingComments()...)
+			}
+		}
+		cmds = append(cmds, m.Cols[i].setEntries(es))
+	}
+	for _, r := range rs {
+		if !slices.ContainsFunc(m.Cols, func(c Column) bool { return c.List.Title == string(r.Stage()) }) {
+			c := NewColumnFromRecord(r, false)
+			c.board = m
+			m.Cols = append(m.Cols, c)
+		}
+	}
+	for i := range m.Cols {
+		m.Cols[i].cnt = uint(len(m.Cols))
+	}
+	m.rebaseHistory(before, m.Records())
+	// the sizes of the columns change if columns were added
+	return tea.Batch(append(cmds, tea.WindowSize())...)
+}
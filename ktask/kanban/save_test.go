@@ -0,0 +1,27 @@
+package kanban
+
+import (
+	"testing"
+
+	"ktask/ktask"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveRefreshesWarnings(t *testing.T) {
+	board := NewDefaultBoard([]Column{NewColumnFromRecord(ktask.NewRecord(ktask.Todo), true)})
+	board.SetWarnings([]string{"line 3: Malformed creation date"})
+	warnings := []string{"line 3: Malformed creation date", "line 7: Invalid stage"}
+	board.SetSave(func(rs []ktask.Record) (Saved, error) {
+		return Saved{Warnings: warnings}, nil
+	}, false, 0)
+
+	board.saveNow(false)
+	assert.Equal(t, "saved", board.status)
+	assert.Equal(t, warnings, board.warnings)
+
+	// the banner disappears once the problems are fixed
+	warnings = nil
+	board.saveNow(false)
+	assert.Empty(t, board.warnings)
+}
@@ -0,0 +1,216 @@
+package ktask
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConflictTagName is the name of the tag marking the copy of an entry which
+// was changed differently by both sides of a merge.
+const ConflictTagName = "conflict"
+
+// Conflict describes an entry which couldn't be merged automatically.
+type Conflict struct {
+	// Entry is the entry as it is kept in the merge result.
+	Entry  Entry
+	Reason string
+}
+
+// placed is an entry together with the stage it belongs to.
+type placed struct {
+	stage Stage
+	entry Entry
+}
+
+// entryKey identifies an entry across different versions of a file. Entries
+// without identifier are identified by their creation date and first line.
+func entryKey(e Entry) string {
+	if e.ID() != "" {
+		return "#" + e.ID()
+	}
+	first := ""
+	if len(e.Name()) > 0 {
+		first = e.Name()[0]
+	}
+	return e.CreatedAt().Format("2006-01-02") + " " + first
+}
+
+// SameEntry checks whether both are versions of the same entry, i.e. they
+// have the same identifier or, without identifier, were created at the same
+// day with the same first line.
+func SameEntry(a, b Entry) bool {
+	return entryKey(a) == entryKey(b)
+}
+
+// index maps the entries to their keys. Entries with the same key (e.g. two
+// identical entries without identifier) are told apart by their occurrence.
+func index(rs []Record) (map[string]placed, []string) {
+	m := map[string]placed{}
+	seen := map[string]int{}
+	var order []string
+	for _, r := range rs {
+		for _, e := range r.Entries() {
+			k := entryKey(e)
+			seen[k]++
+			if n := seen[k]; n > 1 {
+				k += "\x00" + strconv.Itoa(n)
+			}
+			m[k] = placed{r.Stage(), e}
+			order = append(order, k)
+		}
+	}
+	return m, order
+}
+
+func (p placed) equals(o placed) bool {
+	return p.stage == o.stage && p.entry.Equals(o.entry)
+}
+
+// pick does a three-way merge of a single value. The second result is false
+// if both sides changed the value differently.
+func pick[T any](base, ours, theirs T, eq func(a, b T) bool) (T, bool) {
+	switch {
+	case eq(ours, theirs), eq(base, ours):
+		return theirs, true
+	case eq(base, theirs):
+		return ours, true
+	}
+	return theirs, false
+}
+
+// merge merges an entry present in all three versions field by field. If
+// both sides changed a field differently, theirs is kept and false is
+// returned.
+func (base placed) merge(ours, theirs placed) (placed, bool) {
+	stage, okS := pick(base.stage, ours.stage, theirs.stage, func(a, b Stage) bool { return a == b })
+	name, okN := pick(base.entry.Name(), ours.entry.Name(), theirs.entry.Name(), Name.Equals)
+	notes, okD := pick(base.entry.Notes(), ours.entry.Notes(), theirs.entry.Notes(), slices.Equal[[]string])
+	comments, okC := pick(base.entry.Comments(), ours.entry.Comments(), theirs.entry.Comments(), slices.Equal[[]string])
+	modifiedAt := theirs.entry.ModifiedAt()
+	if ours.entry.ModifiedAt().After(modifiedAt) {
+		modifiedAt = ours.entry.ModifiedAt()
+	}
+	e := NewEntry(name, theirs.entry.CreatedAt(), modifiedAt, theirs.entry.Index())
+	e.SetID(theirs.entry.ID())
+	e.SetNotes(notes)
+	e.SetComments(comments)
+	return placed{stage, e}, okS && okN && okD && okC
+}
+
+// MergeRecords does a three-way merge of two versions of records (ours and
+// theirs) which both originate from base. Changes made on only one side are
+// applied. If both sides changed an entry differently, the version of theirs
+// is kept and our version is added as a copy tagged with #conflict. Removing
+// an entry which was changed on the other side keeps the changed entry. The
+// stages are ordered as in theirs, their comments are taken from theirs.
+func MergeRecords(base, ours, theirs []Record) ([]Record, []Conflict) {
+	b, _ := index(base)
+	o, oOrder := index(ours)
+	t, tOrder := index(theirs)
+
+	var conflicts []Conflict
+	// the merged entries in the order they appear in theirs, followed by the
+	// entries which only appear (at that stage) in ours
+	result := map[string]placed{}
+	var copies []placed
+	for _, k := range append(slices.Clone(tOrder), oOrder...) {
+		if _, done := result[k]; done {
+			continue
+		}
+		pb, inB := b[k]
+		po, inO := o[k]
+		pt, inT := t[k]
+		switch {
+		case inO && inT && po.equals(pt):
+			result[k] = pt
+		case inO && inT && inB:
+			merged, ok := pb.merge(po, pt)
+			result[k] = merged
+			if !ok {
+				copies = append(copies, po)
+				conflicts = append(conflicts, Conflict{merged.entry, "changed in both versions"})
+			}
+		case inO && inT:
+			result[k] = pt
+			copies = append(copies, po)
+			conflicts = append(conflicts, Conflict{pt.entry, "added in both versions"})
+		case inT && inB && !pb.equals(pt):
+			result[k] = pt
+			conflicts = append(conflicts, Conflict{pt.entry, "removed here, but changed in the file"})
+		case inO && inB && !pb.equals(po):
+			result[k] = po
+			conflicts = append(conflicts, Conflict{po.entry, "removed in the file, but changed here"})
+		case inT && !inB:
+			result[k] = pt
+		case inO && !inB:
+			result[k] = po
+		default:
+			// removed on one side and unchanged on the other one
+			result[k] = placed{}
+		}
+	}
+
+	var stageOrder []Stage
+	for _, rs := range [][]Record{theirs, ours} {
+		for _, r := range rs {
+			if !slices.Contains(stageOrder, r.Stage()) {
+				stageOrder = append(stageOrder, r.Stage())
+			}
+		}
+	}
+	records := map[Stage]Record{}
+	var merged []Record
+	for _, s := range stageOrder {
+		records[s] = NewRecord(s)
+		merged = append(merged, records[s])
+	}
+	// comments can't be edited along with the entries, so they are taken from
+	// theirs, unless the stage only exists in ours
+	for _, rs := range [][]Record{ours, theirs} {
+		for _, r := range rs {
+			records[r.Stage()].SetComments(r.Comments())
+			records[r.Stage()].SetTrailingComments(r.TrailingComments())
+		}
+	}
+
+	// entries stay at their position in theirs, if they are still at the same
+	// stage, otherwise at their position in ours or they are appended
+	var keys []string
+	for _, k := range tOrder {
+		if p := result[k]; p.stage != "" && p.stage == t[k].stage {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range oOrder {
+		if !slices.Contains(keys, k) && result[k].stage != "" {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range tOrder {
+		if !slices.Contains(keys, k) && result[k].stage != "" {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range keys {
+		p := result[k]
+		records[p.stage].AppendEntry(p.entry)
+	}
+
+	for _, c := range copies {
+		e := c.entry
+		name := slices.Clone(e.Name())
+		if len(name) == 0 {
+			name = Name{""}
+		}
+		name[0] = strings.TrimLeft(name[0]+" #"+ConflictTagName, " ")
+		e.SetName(name)
+		if e.ID() != "" {
+			e.SetID(NewID(merged...))
+		}
+		e.modifiedAt = time.Now()
+		records[c.stage].AppendEntry(e)
+	}
+	return merged, conflicts
+}
@@ -0,0 +1,128 @@
+package ktask_test
+
+import (
+	"ktask/ktask"
+	"ktask/ktask/parser"
+	"testing"
+
+	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, text string) []ktask.Record {
+	rs, _, errs := parser.NewSerialParser().Parse(text)
+	require.Nil(t, errs)
+	return rs
+}
+
+func serialise(rs []ktask.Record) string {
+	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	return parser.SerialiseRecords(ser, rs...).ToString()
+}
+
+func TestMergeRecordsAppliesChangesOfBothSides(t *testing.T) {
+	base := parse(t, `
+todo
+    2024-01-01 2024-01-01 buy milk #id=aaaaaa
+    2024-01-01 2024-01-01 write report #id=bbbbbb
+    2024-01-01 2024-01-01 call mom #id=cccccc
+
+done
+`)
+	// moved one entry and removed another one
+	ours := parse(t, `
+todo
+    2024-01-01 2024-01-01 write report #id=bbbbbb
+
+done
+    2024-01-01 2024-01-02 buy milk #id=aaaaaa
+`)
+	// renamed one entry and added a new one
+	theirs := parse(t, `
+todo
+    2024-01-01 2024-01-01 buy oat milk #id=aaaaaa
+    2024-01-01 2024-01-01 write report #id=bbbbbb
+    2024-01-01 2024-01-01 call mom #id=cccccc
+    2024-01-03 2024-01-03 water plants #id=dddddd
+
+done
+`)
+	merged, conflicts := ktask.MergeRecords(base, ours, theirs)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, `todo
+    2024-01-01 2024-01-01 write report #id=bbbbbb
+    2024-01-03 2024-01-03 water plants #id=dddddd
+
+done
+    2024-01-01 2024-01-02 buy oat milk #id=aaaaaa
+`, serialise(merged))
+}
+
+func TestMergeRecordsReportsConflicts(t *testing.T) {
+	base := parse(t, `
+todo
+    2024-01-01 2024-01-01 buy milk #id=aaaaaa
+    2024-01-01 2024-01-01 call mom #id=cccccc
+`)
+	ours := parse(t, `
+todo
+    2024-01-01 2024-01-02 buy soy milk #id=aaaaaa
+`)
+	theirs := parse(t, `
+todo
+    2024-01-01 2024-01-02 buy oat milk #id=aaaaaa
+    2024-01-01 2024-01-02 call mom and dad #id=cccccc
+`)
+	merged, conflicts := ktask.MergeRecords(base, ours, theirs)
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, "changed in both versions", conflicts[0].Reason)
+	assert.Equal(t, "removed here, but changed in the file", conflicts[1].Reason)
+
+	require.Len(t, merged, 1)
+	es := merged[0].Entries()
+	require.Len(t, es, 3)
+	assert.Equal(t, ktask.Name{"buy oat milk"}, es[0].Name())
+	assert.Equal(t, ktask.Name{"call mom and dad"}, es[1].Name())
+	// our version is kept as a copy with a new identifier
+	assert.Equal(t, ktask.Name{"buy soy milk #conflict"}, es[2].Name())
+	assert.NotEqual(t, "aaaaaa", es[2].ID())
+}
+
+func TestMergeRecordsKeepsEntriesWithSameKey(t *testing.T) {
+	base := parse(t, `
+todo
+    2024-01-02 2024-01-02 same
+    2024-01-02 2024-01-02 same
+`)
+	ours := parse(t, `
+todo
+    2024-01-02 2024-01-02 same
+    2024-01-02 2024-01-02 same
+`)
+	theirs := parse(t, `
+todo
+    2024-01-02 2024-01-02 same
+    2024-01-02 2024-01-02 same
+    2024-01-03 2024-01-03 other
+`)
+	merged, conflicts := ktask.MergeRecords(base, ours, theirs)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, `todo
+    2024-01-02 2024-01-02 same
+    2024-01-02 2024-01-02 same
+    2024-01-03 2024-01-03 other
+`, serialise(merged))
+
+	// removing one of them on one side removes only that one
+	ours = parse(t, `
+todo
+    2024-01-02 2024-01-02 same
+`)
+	merged, conflicts = ktask.MergeRecords(base, ours, theirs)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, `todo
+    2024-01-02 2024-01-02 same
+    2024-01-03 2024-01-03 other
+`, serialise(merged))
+}
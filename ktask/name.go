@@ -11,9 +11,13 @@ type Name []string
 var nameLinePattern = regexp.MustCompile("^[\\p{Zs}\t]*$")
 
 // NewEntry creates a Name from individual lines of text.
-// Except for the first line, none of the lines can be empty or blank.
+// Except for the first line, none of the lines can be empty or blank and no
+// line may contain a line break.
 func NewName(line ...string) (Name, error) {
 	for i, l := range line {
+		if strings.ContainsAny(l, "\r\n") {
+			return nil, errors.New("MALFORMED_SUMMARY")
+		}
 		if i == 0 {
 			continue
 		}
@@ -28,12 +32,17 @@ func (s Name) Lines() []string {
 	return s
 }
 
+// LinesWithoutFirstTag returns the lines without the project tag (see
+// Project).
 func (s Name) LinesWithoutFirstTag() []string {
 	var ret []string
 	found := false
 	for _, l := range s {
 		if !found {
 			for _, m := range HashTagPattern.FindAllStringSubmatch(l, -1) {
+				if t, err := NewTagFromString(m[0]); err != nil || isSpecialTag(t) {
+					continue
+				}
 				l = strings.ReplaceAll(l, m[0]+" ", "")
 				l = strings.ReplaceAll(l, m[0], "")
 				found = true
@@ -45,6 +54,141 @@ func (s Name) LinesWithoutFirstTag() []string {
 	return ret
 }
 
+// Project returns the first tag which doesn't have a special meaning (like
+// the due date or the priority). It is interpreted as the project the entry belongs to.
+func (s Name) Project() (Tag, bool) {
+	for _, t := range s.Tags().All() {
+		if !isSpecialTag(t) {
+			return t, true
+		}
+	}
+	return Tag{}, false
+}
+
+// isSpecialTag checks whether the tag has a special meaning and thus
+// cannot be the project.
+func isSpecialTag(t Tag) bool {
+	return t.Name() == DueTagName || t.Name() == PrioTagName || t.Name() == ConflictTagName
+}
+
+// WithoutTags returns the name with all tags removed. Lines which only
+// consisted of tags are dropped, except for the first one.
+func (s Name) WithoutTags() Name {
+	var ret Name
+	for i, l := range s {
+		l = strings.Join(strings.Fields(HashTagPattern.ReplaceAllString(l, "")), " ")
+		if i == 0 || l != "" {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+// WithTags returns a copy of the name with the tags put in front of the first
+// line.
+func (s Name) WithTags(tags ...Tag) Name {
+	ret := append(Name{}, s...)
+	if len(ret) == 0 {
+		ret = Name{""}
+	}
+	for i := len(tags) - 1; i >= 0; i-- {
+		ret[0] = strings.TrimRight(tags[i].ToString()+" "+ret[0], " ")
+	}
+	return ret
+}
+
+// WithTitle returns a copy of the name with the text of the first line
+// replaced. The tags of the first line are kept in front of or behind the
+// text, depending on where they were, the other lines are kept as they are.
+func (s Name) WithTitle(title string) Name {
+	ret := append(Name{}, s...)
+	if len(ret) == 0 {
+		return Name{title}
+	}
+	var lead, trail []string
+	pos, text := 0, false
+	for _, m := range HashTagPattern.FindAllStringIndex(ret[0], -1) {
+		text = text || strings.TrimSpace(ret[0][pos:m[0]]) != ""
+		if text {
+			trail = append(trail, ret[0][m[0]:m[1]])
+		} else {
+			lead = append(lead, ret[0][m[0]:m[1]])
+		}
+		pos = m[1]
+	}
+	ret[0] = strings.Join(append(append(lead, title), trail...), " ")
+	return ret
+}
+
+// ReplaceTags returns a copy of the name with the tags replaced. Tags which
+// are kept (possibly with another value) stay where they are, the other ones
+// are removed. New tags are appended to the first line, except for a new
+// project (the first tag without special meaning), which is put in front.
+// Lines which only consisted of removed tags are dropped, except for the
+// first one.
+func (s Name) ReplaceTags(tags ...Tag) Name {
+	byName := map[string]Tag{}
+	var project Tag
+	for _, t := range tags {
+		if _, ok := byName[t.Name()]; !ok {
+			byName[t.Name()] = t
+		}
+		if project.Name() == "" && !isSpecialTag(t) {
+			project = t
+		}
+	}
+	// the project has to stay the first tag without special meaning
+	first := ""
+	for _, t := range s.Tags().All() {
+		if _, ok := byName[t.Name()]; ok && !isSpecialTag(t) {
+			first = t.Name()
+			break
+		}
+	}
+	moveProject := project.Name() != "" && first != project.Name()
+	if moveProject {
+		delete(byName, project.Name())
+	}
+
+	placed := map[string]bool{}
+	var ret Name
+	for i, l := range s {
+		replaced := HashTagPattern.ReplaceAllStringFunc(l, func(m string) string {
+			t, err := NewTagFromString(m)
+			if err != nil {
+				return m
+			}
+			n, ok := byName[t.Name()]
+			if !ok || placed[t.Name()] {
+				return ""
+			}
+			placed[t.Name()] = true
+			return n.ToString()
+		})
+		if replaced != l {
+			// only the lines with removed or changed tags are rewritten
+			l = strings.Join(strings.Fields(replaced), " ")
+		}
+		if i == 0 || l != "" {
+			ret = append(ret, l)
+		}
+	}
+	if len(ret) == 0 {
+		ret = Name{""}
+	}
+	if moveProject {
+		ret = ret.WithTags(project)
+		placed[project.Name()] = true
+	}
+	for _, t := range tags {
+		if !placed[t.Name()] {
+			ret[0] = strings.TrimLeft(ret[0]+" "+t.ToString(), " ")
+			placed[t.Name()] = true
+		}
+	}
+	return ret
+}
+
 func (s Name) Tags() *TagSet {
 	tags := NewEmptyTagSet()
 	for _, l := range s {
@@ -0,0 +1,277 @@
+/*
+Package csv contains the logic of serialising Record’s as CSV with one row per
+entry and reading them back, also from files written by other tools.
+*/
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"ktask/ktask"
+	"slices"
+	"strings"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// The columns of the CSV, the values of the tags follow as one column per tag
+// name with TagColumnPrefix in front of the name.
+const (
+	StageColumn     = "stage"
+	IDColumn        = "id"
+	CreatedColumn   = "created"
+	ModifiedColumn  = "modified"
+	TitleColumn     = "title"
+	ProjectColumn   = "project"
+	TagsColumn      = "tags"
+	NotesColumn     = "notes"
+	TagColumnPrefix = "tag:"
+)
+
+var columns = []string{StageColumn, IDColumn, CreatedColumn, ModifiedColumn, TitleColumn, ProjectColumn, TagsColumn, NotesColumn}
+
+// ToCsv serialises the entries of the records as CSV, the first row is the
+// header. The title is the name without the tags, multiple lines of the notes
+// are separated by line breaks within the cell. The tags column
+// contains all tags (including the project), additionally the value of each
+// tag is put in the column of its name, e.g. `tag:due`.
+func ToCsv(rs []ktask.Record) string {
+	var tagNames []string
+	for _, r := range rs {
+		for _, e := range r.Entries() {
+			for _, t := range e.Name().Tags().All() {
+				if t.Value() != "" && !slices.Contains(tagNames, t.Name()) {
+					tagNames = append(tagNames, t.Name())
+				}
+			}
+		}
+	}
+	slices.Sort(tagNames)
+
+	header := append([]string{}, columns...)
+	for _, n := range tagNames {
+		header = append(header, TagColumnPrefix+n)
+	}
+	rows := [][]string{header}
+	for _, r := range rs {
+		for _, e := range r.Entries() {
+			project := ""
+			if p, ok := e.Name().Project(); ok {
+				project = strings.TrimPrefix(p.ToString(), "#")
+			}
+			row := []string{
+				string(r.Stage()),
+				e.ID(),
+				e.CreatedAt().Format(dateFormat),
+				e.ModifiedAt().Format(dateFormat),
+				strings.Join(e.Name().WithoutTags(), " "),
+				project,
+				strings.Join(e.Name().Tags().ToStrings(), " "),
+				strings.Join(e.Notes(), "\n"),
+			}
+			for _, n := range tagNames {
+				row = append(row, tagValue(e.Name(), n))
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	buffer := new(bytes.Buffer)
+	w := csv.NewWriter(buffer)
+	if err := w.WriteAll(rows); err != nil {
+		panic(err) // This should never happen
+	}
+	return buffer.String()
+}
+
+// FromCsv reads records from CSV as written by ToCsv. The first row is the
+// header, columns are matched case-insensitively and unknown columns are
+// ignored. The mapping renames columns of files written by other tools to the
+// ones of ktask, e.g. `Status` to `stage`. Stage, created and title are
+// required, modified defaults to created. The entries are grouped by stage in
+// the order of their first occurrence.
+func FromCsv(data []byte, mapping map[string]string) ([]ktask.Record, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the CSV has no header")
+	}
+
+	lowerMapping := map[string]string{}
+	for from, to := range mapping {
+		to = strings.ToLower(strings.TrimSpace(to))
+		if !slices.Contains(columns, to) && !strings.HasPrefix(to, TagColumnPrefix) {
+			return nil, fmt.Errorf("unknown column %q in the mapping of %q", to, from)
+		}
+		lowerMapping[strings.ToLower(strings.TrimSpace(from))] = to
+	}
+	index := map[string]int{}
+	for i, h := range rows[0] {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if to, ok := lowerMapping[h]; ok {
+			h = to
+		}
+		if _, ok := index[h]; !ok {
+			index[h] = i
+		}
+	}
+	for _, c := range []string{StageColumn, CreatedColumn, TitleColumn} {
+		if _, ok := index[c]; !ok {
+			return nil, fmt.Errorf("the CSV has no %q column", c)
+		}
+	}
+
+	var rs []ktask.Record
+	for i, row := range rows[1:] {
+		get := func(column string) string {
+			if c, ok := index[column]; ok && c < len(row) {
+				return strings.TrimSpace(row[c])
+			}
+			return ""
+		}
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		stage := ktask.Stage(get(StageColumn))
+		if err := stage.Valid(); err != nil {
+			return nil, fmt.Errorf("row %d: invalid stage %q", i+2, stage)
+		}
+		ri := slices.IndexFunc(rs, func(r ktask.Record) bool { return r.Stage() == stage })
+		if ri < 0 {
+			rs = append(rs, ktask.NewRecord(stage))
+			ri = len(rs) - 1
+		}
+		e, err := fromRow(get, index, len(rs[ri].Entries()))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		rs[ri].AppendEntry(e)
+	}
+	return rs, nil
+}
+
+func fromRow(get func(string) string, index map[string]int, i int) (ktask.Entry, error) {
+	createdAt, err := parseDate(get(CreatedColumn))
+	if err != nil {
+		return ktask.Entry{}, fmt.Errorf("invalid created %q, expected YYYY-MM-DD", get(CreatedColumn))
+	}
+	modifiedAt := createdAt
+	if get(ModifiedColumn) != "" {
+		if modifiedAt, err = parseDate(get(ModifiedColumn)); err != nil {
+			return ktask.Entry{}, fmt.Errorf("invalid modified %q, expected YYYY-MM-DD", get(ModifiedColumn))
+		}
+	}
+
+	tags, err := parseTags(get(TagsColumn))
+	if err != nil {
+		return ktask.Entry{}, err
+	}
+	if p := get(ProjectColumn); p != "" {
+		project, err := ktask.NewTagFromString(p)
+		if err != nil {
+			return ktask.Entry{}, fmt.Errorf("invalid project %q", p)
+		}
+		if !slices.ContainsFunc(tags, func(t ktask.Tag) bool { return t.Name() == project.Name() }) {
+			tags = append([]ktask.Tag{project}, tags...)
+		}
+	}
+	var valueColumns []string
+	for c := range index {
+		if strings.HasPrefix(c, TagColumnPrefix) {
+			valueColumns = append(valueColumns, c)
+		}
+	}
+	slices.Sort(valueColumns)
+	for _, c := range valueColumns {
+		name, value := strings.TrimPrefix(c, TagColumnPrefix), get(c)
+		if value == "" || slices.ContainsFunc(tags, func(t ktask.Tag) bool { return t.Name() == name && t.Value() != "" }) {
+			continue
+		}
+		t, err := ktask.NewTag(name, value)
+		if err != nil {
+			return ktask.Entry{}, fmt.Errorf("invalid tag %q=%q", name, value)
+		}
+		tags = append(tags, t)
+	}
+
+	title := get(TitleColumn)
+	if title == "" {
+		return ktask.Entry{}, errors.New("the title is missing")
+	}
+	// the title is a single line, line breaks (e.g. of other tools) are
+	// joined with blanks
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(title, "\r\n", "\n"), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		return ktask.Entry{}, errors.New("the title is missing")
+	}
+	name, id := ktask.SplitID(ktask.Name{strings.Join(lines, " ")}.WithTags(tags...))
+	if v := get(IDColumn); v != "" {
+		if t, err := ktask.NewTagFromString(ktask.IdTagName + "=" + v); err != nil || t.Value() != v {
+			return ktask.Entry{}, fmt.Errorf("invalid id %q", v)
+		}
+		id = v
+	}
+
+	e := ktask.NewEntry(name, createdAt, modifiedAt, i)
+	e.SetID(id)
+	if notes := get(NotesColumn); notes != "" {
+		e.SetNotes(strings.Split(strings.ReplaceAll(notes, "\r\n", "\n"), "\n"))
+	}
+	return e, nil
+}
+
+// parseTags reads the tags column. It either contains tags as written in the
+// ktask format, e.g. `#grocery #due=2024-01-05`, or (as written by other
+// tools) a list of words separated by commas or blanks.
+func parseTags(s string) ([]ktask.Tag, error) {
+	var words []string
+	if strings.Contains(s, "#") {
+		if rest := strings.TrimSpace(ktask.HashTagPattern.ReplaceAllString(s, "")); rest != "" {
+			return nil, fmt.Errorf("invalid tags %q", s)
+		}
+		words = ktask.HashTagPattern.FindAllString(s, -1)
+	} else {
+		words = strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	}
+	var tags []ktask.Tag
+	for _, w := range words {
+		t, err := ktask.NewTagFromString(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag %q", w)
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// parseDate reads a date in the form YYYY-MM-DD. Timestamps starting with
+// such a date (as exported by many tools) are accepted as well.
+func parseDate(s string) (time.Time, error) {
+	if len(s) > len(dateFormat) && (s[len(dateFormat)] == 'T' || s[len(dateFormat)] == ' ') {
+		s = s[:len(dateFormat)]
+	}
+	return time.Parse(dateFormat, s)
+}
+
+// tagValue returns the value of the first tag with the name, or an empty
+// string if there is none.
+func tagValue(name ktask.Name, tagName string) string {
+	for _, t := range name.Tags().All() {
+		if t.Name() == tagName && t.Value() != "" {
+			return t.Value()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package csv
+
+import (
+	"ktask/ktask"
+	"ktask/ktask/parser"
+	"testing"
+
+	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCsvRoundTrip(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 #grocery #due=2024-01-05 buy milk #id=3fa2c1
+        two bottles
+        low fat
+    2024-01-02 2024-01-03 #prio=A #where="living room" water plants, all of them
+
+done
+    2023-12-01 2024-01-01 celebrate
+`
+	rs, _, errs := parser.NewSerialParser().Parse(text)
+	require.Nil(t, errs)
+
+	out := ToCsv(rs)
+	assert.Equal(t, "stage,id,created,modified,title,project,tags,notes,tag:due,tag:prio,tag:where\n"+
+		"todo,3fa2c1,2024-01-02,2024-01-03,buy milk,grocery,#grocery #due=2024-01-05,\"two bottles\nlow fat\",2024-01-05,,\n"+
+		"todo,,2024-01-02,2024-01-03,\"water plants, all of them\",\"where=\"\"living room\"\"\",\"#prio=A #where=\"\"living room\"\"\",,,A,living room\n"+
+		"done,,2023-12-01,2024-01-01,celebrate,,,,,,\n", out)
+
+	imported, err := FromCsv([]byte(out), nil)
+	require.Nil(t, err)
+	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	assert.Equal(t, text, parser.SerialiseRecords(ser, imported...).ToString())
+}
+
+func TestCsvImportWithMapping(t *testing.T) {
+	data := "Status,Task,Created On,Labels,Deadline,Comment\n" +
+		"To Do,buy milk,2024-01-02T10:00:00Z,\"grocery, urgent\",2024-01-05,two bottles\n" +
+		"Done,celebrate,2023-12-01,,,\n"
+	mapping := map[string]string{
+		"Status":     "stage",
+		"Task":       "title",
+		"Created On": "created",
+		"Labels":     "tags",
+		"deadline":   "tag:due",
+	}
+	rs, err := FromCsv([]byte(data), mapping)
+	require.Nil(t, err)
+	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	assert.Equal(t, `To Do
+    2024-01-02 2024-01-02 #grocery #urgent #due=2024-01-05 buy milk
+
+Done
+    2023-12-01 2023-12-01 celebrate
+`, parser.SerialiseRecords(ser, rs...).ToString())
+
+	for _, invalid := range []string{
+		"stage,title\ntodo,buy milk\n",
+		"stage,created,title\ntodo,02.01.2024,buy milk\n",
+		"stage,created,title\ntodo,2024-01-02,\n",
+		"stage,created,title,tags\ntodo,2024-01-02,buy milk,#a=\"b\n",
+		// line breaks are only allowed in the title and the notes
+		"stage,created,title,tag:x\ntodo,2024-01-02,buy milk,\"a\nb\"\n",
+		"stage,created,title,project\ntodo,2024-01-02,buy milk,\"a=\"\"b\nc\"\"\"\n",
+		"stage,created,title,tags\ntodo,2024-01-02,buy milk,\"#a=\"\"b\nc\"\"\"\n",
+		"stage,created,title,tags\ntodo,2024-01-02,buy milk,\"a\nb\"\n",
+		"stage,created,title\n\"to\ndo\",2024-01-02,buy milk\n",
+	} {
+		_, err := FromCsv([]byte(invalid), nil)
+		assert.NotNil(t, err, invalid)
+	}
+	_, err = FromCsv([]byte(data), map[string]string{"Status": "state"})
+	assert.NotNil(t, err)
+
+	// the title is a single line
+	rs, err = FromCsv([]byte("stage,created,title\ntodo,2024-01-02,\" buy\r\n\n milk #grocery \"\n"), nil)
+	require.Nil(t, err)
+	assert.Equal(t, ktask.Name{"buy milk #grocery"}, rs[0].Entries()[0].Name())
+	_, err = FromCsv([]byte("stage,created,title\ntodo,2024-01-02,\"\n \n\"\n"), nil)
+	assert.NotNil(t, err)
+}
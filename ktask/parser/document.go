@@ -0,0 +1,253 @@
+package parser
+
+import (
+	"ktask/ktask"
+	"slices"
+	"strings"
+
+	"github.com/jotaen/klog/klog/parser/txt"
+)
+
+// Document remembers the original text of parsed records, so that they can
+// be written back with minimal changes.
+type Document struct {
+	chunks []chunk
+	// raw maps the comments which replaced quarantined lines to these lines
+	raw map[string]txt.Line
+	// blankEnd is the number of blank lines at the end of the text
+	blankEnd int
+	// lineEnding is the first line ending of the text, which is used where
+	// a record doesn't tell
+	lineEnding string
+}
+
+// chunk is the text of one record, split up into its parts.
+type chunk struct {
+	stage ktask.Stage
+	// entries are the entries as parsed, entryLines the respective lines
+	// including their comments
+	entries    []ktask.Entry
+	entryLines [][]txt.Line
+	// comments and trailing are the comments of the record as parsed
+	comments []string
+	trailing []string
+	head     []txt.Line // blank and comment lines before the headline
+	headline txt.Line
+	tail     []txt.Line // comment and blank lines after the last entry
+	// indentation and lineEnding are the style of the record, which is used
+	// for new or modified entries
+	indentation string
+	lineEnding  string
+}
+
+// NewDocument creates a document from the records and blocks returned by
+// Parser.Parse or ParseLenient. It must be created before the records are
+// modified.
+func NewDocument(rs []ktask.Record, bs []txt.Block) *Document {
+	d := &Document{raw: map[string]txt.Line{}, lineEnding: canonicalLineEnding}
+	for _, b := range bs {
+		if i := slices.IndexFunc(b.Lines(), func(l txt.Line) bool { return l.LineEnding != "" }); i >= 0 {
+			d.lineEnding = b.Lines()[i].LineEnding
+			break
+		}
+	}
+	for i, r := range rs {
+		if q, ok := bs[i].(quarantinedBlock); ok {
+			for k, l := range q.raw {
+				d.raw[k] = l
+			}
+		}
+		lines := bs[i].Lines()
+		c := chunk{
+			stage:       r.Stage(),
+			entries:     slices.Clone(r.Entries()),
+			comments:    slices.Clone(r.Comments()),
+			trailing:    slices.Clone(r.TrailingComments()),
+			indentation: canonicalIndentation,
+		}
+		if IsCommentRecord(r) {
+			c.head, c.lineEnding = lines, d.lineEnding
+			d.chunks = append(d.chunks, c)
+			continue
+		}
+		pos := 0
+		for pos < len(lines) && (lines[pos].IsBlank() || isComment(lines[pos])) {
+			pos++
+		}
+		c.head, c.headline = lines[:pos], lines[pos]
+		c.lineEnding = c.headline.LineEnding
+		if c.lineEnding == "" {
+			c.lineEnding = d.lineEnding
+		}
+		lines = lines[pos+1:]
+		if first := slices.IndexFunc(lines, func(l txt.Line) bool { return !l.IsBlank() && !isComment(l) }); first >= 0 {
+			c.indentation = lines[first].Indentation()
+		}
+
+		// Every line which is indented exactly once starts a new entry, lines
+		// which are indented further belong to the previous one. Comments
+		// belong to the entry following them.
+		var pending []txt.Line
+		for _, l := range lines {
+			if l.IsBlank() || isComment(l) {
+				pending = append(pending, l)
+				continue
+			}
+			rest := strings.TrimPrefix(l.Text, c.indentation)
+			if len(c.entryLines) == 0 || (rest != "" && !txt.IsSpaceOrTab([]rune(rest)[0])) {
+				c.entryLines = append(c.entryLines, nil)
+			}
+			c.entryLines[len(c.entryLines)-1] = append(c.entryLines[len(c.entryLines)-1], append(pending, l)...)
+			pending = nil
+		}
+		c.tail = pending
+		d.chunks = append(d.chunks, c)
+	}
+	if len(bs) > 0 {
+		d.blankEnd = blankEnd(bs[len(bs)-1].Lines())
+	}
+	return d
+}
+
+// blankEnd returns the number of blank lines at the end.
+func blankEnd(lines []txt.Line) int {
+	n := 0
+	for n < len(lines) && lines[len(lines)-1-n].IsBlank() {
+		n++
+	}
+	return n
+}
+
+// Serialise returns the text of the records. Records and entries which were
+// not changed since parsing keep their original text including indentation,
+// line endings and blank lines. Modified entries are serialised in the style
+// of the record they are in, new records in the canonical style but with the
+// line ending of the text.
+func (d *Document) Serialise(s Serialiser, rs ...ktask.Record) string {
+	var lines []txt.Line
+	// separate makes sure there is a blank line before the next record
+	separate := func() {
+		if len(lines) > 0 && !lines[len(lines)-1].IsBlank() {
+			lines = append(lines, txt.Line{Text: "", LineEnding: d.lineEnding})
+		}
+	}
+
+	used := make([]bool, len(d.chunks))
+	// the head of the text, i.e. the comments before the first stage (such as
+	// the declaration of the stages), stays at the top whatever the order of
+	// the records, even if they aren't part of the records anymore
+	if len(d.chunks) > 0 {
+		lines = append(lines, d.chunks[0].head...)
+	}
+	head := len(lines)
+	for _, r := range rs {
+		ci := slices.IndexFunc(d.chunks, func(c chunk) bool { return c.stage == r.Stage() })
+		for ci >= 0 && used[ci] {
+			next := slices.IndexFunc(d.chunks[ci+1:], func(c chunk) bool { return c.stage == r.Stage() })
+			if next < 0 {
+				ci = -1
+				break
+			}
+			ci += 1 + next
+		}
+		if ci < 0 {
+			separate()
+			for _, l := range serialiseRecord(s, r) {
+				lines = append(lines, txt.Line{Text: l.Text, LineEnding: d.lineEnding})
+			}
+			continue
+		}
+
+		used[ci] = true
+		c := d.chunks[ci]
+		switch {
+		case ci == 0 && slices.Equal(r.Comments(), c.comments):
+			// the head was already written
+			if len(lines) > head {
+				separate()
+			}
+		case slices.Equal(r.Comments(), c.comments):
+			if len(c.head) == 0 || !c.head[0].IsBlank() {
+				separate()
+			}
+			lines = append(lines, c.head...)
+		default:
+			separate()
+			for _, comment := range r.Comments() {
+				lines = append(lines, txt.Line{Text: commentLine(s, "", comment), LineEnding: c.lineEnding})
+			}
+		}
+		if IsCommentRecord(r) {
+			continue
+		}
+		lines = append(lines, c.headline)
+		for _, e := range r.Entries() {
+			if original := d.originalLines(e, c); original != nil {
+				lines = append(lines, original...)
+				continue
+			}
+			for _, l := range serialiseEntry(s, r, e, -1, c.indentation) {
+				lines = append(lines, txt.Line{Text: l.Text, LineEnding: c.lineEnding})
+			}
+		}
+		if slices.Equal(r.TrailingComments(), c.trailing) {
+			lines = append(lines, c.tail...)
+		} else {
+			for _, l := range serialiseTrailingComments(s, r, c.indentation) {
+				lines = append(lines, txt.Line{Text: l.Text, LineEnding: c.lineEnding})
+			}
+		}
+	}
+
+	// the blank lines which separated a record from the next one aren't
+	// needed anymore if it became the last one
+	if extra := blankEnd(lines) - d.blankEnd; extra > 0 {
+		lines = lines[:len(lines)-extra]
+	}
+
+	builder := strings.Builder{}
+	for i, l := range lines {
+		if original, ok := d.raw[l.Text]; ok {
+			l.Text = original.Text
+		}
+		builder.WriteString(l.Text)
+		if l.LineEnding == "" && i < len(lines)-1 {
+			// the original last line of the file might not be the last one anymore
+			l.LineEnding = d.lineEnding
+		}
+		builder.WriteString(l.LineEnding)
+	}
+	return builder.String()
+}
+
+// Contains checks whether the text contained a record of the stage.
+func (d *Document) Contains(s ktask.Stage) bool {
+	return slices.ContainsFunc(d.chunks, func(c chunk) bool { return c.stage == s })
+}
+
+// Comments returns the comments of a text without any stage, see
+// IsCommentRecord. They are written back by Serialise even if the record
+// holding them was dropped.
+func (d *Document) Comments() []string {
+	if ci := slices.IndexFunc(d.chunks, func(c chunk) bool { return c.stage == "" }); ci >= 0 {
+		return d.chunks[ci].comments
+	}
+	return nil
+}
+
+// originalLines returns the original text of an unchanged entry, if it can be
+// used within the chunk. Entries of other records can be reused if the
+// indentation is the same.
+func (d *Document) originalLines(e ktask.Entry, target chunk) []txt.Line {
+	for _, c := range append([]chunk{target}, d.chunks...) {
+		if c.indentation != target.indentation {
+			continue
+		}
+		for i, o := range c.entries {
+			if o.Equals(e) && i < len(c.entryLines) {
+				return c.entryLines[i]
+			}
+		}
+	}
+	return nil
+}
@@ -21,18 +21,116 @@ type Parser interface {
 // NewSerialParser returns a new parser, which processes the input text
 // serially, i.e. one after the other.
 func NewSerialParser() Parser {
-	return serialParser
+	return commentParser{serialParser}
 }
 
 // NewParallelParser returns a new parser, which processes the input text
 // in parallel. The parsing result is the same as with the serial parser.
 func NewParallelParser(numberOfWorkers int) Parser {
-	return engine.ParallelBatchParser[ktask.Record]{
+	return commentParser{engine.ParallelBatchParser[ktask.Record]{
 		SerialParser:    serialParser,
 		NumberOfWorkers: numberOfWorkers,
-	}
+	}}
+}
+
+// NewSerialParserWithStages returns a new serial parser, which only accepts
+// the declared stages in headlines.
+func NewSerialParserWithStages(stages ktask.Stages) Parser {
+	return commentParser{engine.SerialParser[ktask.Record]{
+		ParseOne: func(b txt.Block) (ktask.Record, []txt.Error) {
+			return parse(b, stages)
+		},
+	}}
 }
 
 var serialParser = engine.SerialParser[ktask.Record]{
-	ParseOne: parse,
+	ParseOne: func(b txt.Block) (ktask.Record, []txt.Error) {
+		return parse(b, nil)
+	},
+}
+
+// commentParser attaches blocks which only consist of comments to the next
+// record, or if there is none, to the end of the last record. The blocks are
+// joined accordingly. If there is no record at all, the comments are returned
+// as a record without stage (see IsCommentRecord).
+type commentParser struct {
+	Parser
+}
+
+func (p commentParser) Parse(text string) ([]ktask.Record, []txt.Block, []txt.Error) {
+	rs, bs, errs := p.Parser.Parse(text)
+	if errs != nil {
+		return nil, nil, errs
+	}
+	var records []ktask.Record
+	var blocks []txt.Block
+	var comments []string
+	var pending []txt.Block
+	for i, r := range rs {
+		if r.Stage() == "" {
+			comments = append(comments, r.Comments()...)
+			pending = append(pending, bs[i])
+			continue
+		}
+		r.SetComments(append(comments, r.Comments()...))
+		records = append(records, r)
+		blocks = append(blocks, joinBlocks(append(pending, bs[i])...))
+		comments, pending = nil, nil
+	}
+	switch {
+	case len(pending) > 0 && len(records) > 0:
+		last := records[len(records)-1]
+		last.SetTrailingComments(append(last.TrailingComments(), comments...))
+		blocks[len(blocks)-1] = joinBlocks(append([]txt.Block{blocks[len(blocks)-1]}, pending...)...)
+	case len(pending) > 0:
+		// A text without any stage keeps its comments in a record without
+		// stage, otherwise they would be lost when writing the text back.
+		r := ktask.NewRecord("")
+		r.SetComments(comments)
+		records = append(records, r)
+		blocks = append(blocks, joinBlocks(pending...))
+	}
+	return records, blocks, nil
+}
+
+// IsCommentRecord checks whether the record only holds the comments of a text
+// without any stage.
+func IsCommentRecord(r ktask.Record) bool {
+	return r.Stage() == ""
+}
+
+// joinedBlock consists of multiple consecutive blocks.
+type joinedBlock struct {
+	txt.Block
+	lines []txt.Line
+}
+
+func joinBlocks(bs ...txt.Block) txt.Block {
+	if len(bs) == 1 {
+		return bs[0]
+	}
+	var lines []txt.Line
+	for _, b := range bs {
+		lines = append(lines, b.Lines()...)
+	}
+	return joinedBlock{bs[0], lines}
+}
+
+func (b joinedBlock) Lines() []txt.Line {
+	return b.lines
+}
+
+// SignificantLines returns the lines from the first to the last non-blank
+// line, which may contain blank lines in between.
+func (b joinedBlock) SignificantLines() ([]txt.Line, int, int) {
+	first, last := len(b.lines), len(b.lines)
+	for i, l := range b.lines {
+		if !l.IsBlank() {
+			if first == len(b.lines) {
+				first = i
+			}
+			last = i + 1
+		}
+	}
+	return b.lines[first:last], first, len(b.lines) - last
 }
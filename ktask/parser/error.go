@@ -6,18 +6,50 @@ type HumanError struct {
 	code    string
 	title   string
 	details string
+	// fix is a short suggestion how to resolve the error.
+	fix string
+}
+
+type txtError = txt.Error
+
+// humanError is a parsing error which can also suggest a fix.
+type humanError struct {
+	txtError
+	fix string
+}
+
+// Fix returns a suggestion how to resolve the error.
+func (e humanError) Fix() string {
+	return e.fix
+}
+
+func (e humanError) SetOrigin(origin string) txt.Error {
+	e.txtError = e.txtError.SetOrigin(origin)
+	return e
 }
 
 func (e HumanError) New(b txt.Block, line int, start int, length int) txt.Error {
-	return txt.NewError(b, line, start, length, e.code, e.title, e.details)
+	return humanError{txt.NewError(b, line, start, length, e.code, e.title, e.details), e.fix}
 }
 
 func ErrorInvalidStage() HumanError {
 	return HumanError{
 		"ErrorInvalidStage",
 		"Invalid stage",
-		"The highlighted value is not recognised as stage. " +
-			"Please check if the stage is formatted correctly",
+		"The highlighted headline is not recognised as stage. " +
+			"A stage is a single line of text without leading whitespace. " +
+			"If stages were declared, it must be one of them.",
+		"Correct the name of the stage or add it to the declared stages (--stages or // stages:).",
+	}
+}
+
+func ErrorInvalidStageDeclaration() HumanError {
+	return HumanError{
+		"ErrorInvalidStageDeclaration",
+		"Invalid declaration of stages",
+		"The highlighted comment declares the stages of the board. " +
+			"Each stage must be a single line of text and may only be declared once.",
+		"List the stages separated by commas, e.g.: // stages: todo, in progress, done",
 	}
 }
 
@@ -25,8 +57,12 @@ func ErrorIllegalIndentation() HumanError {
 	return HumanError{
 		"ErrorIllegalIndentation",
 		"Unexpected indentation",
-		"Please correct the indentation of this line. Indentation must be 2-4 spaces or one tab. " +
-			"You cannot mix different indentation styles within the same record.",
+		"Please correct the indentation of this line. Stages are not indented, " +
+			"entries are indented once and their notes twice. " +
+			"Indentation must be 2-4 spaces or one tab. " +
+			"You cannot mix different indentation styles within the same stage.",
+		"Indent the line like the other entries of the stage, " +
+			"or separate a new stage with a blank line.",
 	}
 }
 
@@ -34,27 +70,66 @@ func ErrorUnrecognisedTextInHeadline() HumanError {
 	return HumanError{
 		"ErrorUnrecognisedTextInHeadline",
 		"Malformed headline",
-		"The highlighted text in the headline is not recognised. " +
-			"Please make sure to surround the should-total with parentheses, e.g.: (5h!) " +
-			"You generally cannot put arbitrary text into the headline.",
+		"The highlighted text follows the name of the stage. " +
+			"The headline must only consist of the name of a declared stage.",
+		"Remove the highlighted text or put it into a comment line starting with // or ;",
+	}
+}
+
+func ErrorMalformedNotes() HumanError {
+	return HumanError{
+		"ErrorMalformedNotes",
+		"Malformed notes",
+		"Notes cannot start with blank characters, such as non-breaking spaces.",
+		"Remove the blank characters at the beginning of the line.",
+	}
+}
+
+func ErrorMalformedCreatedAt() HumanError {
+	return HumanError{
+		"ErrorMalformedCreatedAt",
+		"Malformed creation date",
+		"An entry must start with the date it was created, " +
+			"followed by the date it was last modified and its title.",
+		"Use the form YYYY-MM-DD, e.g.: 2024-01-02 2024-01-05 buy milk",
+	}
+}
+
+func ErrorMalformedModifiedAt() HumanError {
+	return HumanError{
+		"ErrorMalformedModifiedAt",
+		"Malformed modification date",
+		"The creation date of an entry must be followed by the date " +
+			"the entry was last modified.",
+		"Use the form YYYY-MM-DD, e.g.: 2024-01-02 2024-01-05 buy milk",
+	}
+}
+
+func ErrorInvalidTag() HumanError {
+	return HumanError{
+		"ErrorInvalidTag",
+		"Invalid tag",
+		"The highlighted tag is malformed. A tag consists of letters, digits, " +
+			"_ and -, optionally followed by = and a value. " +
+			"Values containing other characters must be quoted.",
+		`Write the tag like #name or #name=value or #name="some value"`,
 	}
 }
 
-func ErrorMalformedSummary() HumanError {
+func ErrorMalformedDueDate() HumanError {
 	return HumanError{
-		"ErrorMalformedSummary",
-		"Malformed summary",
-		"Summary lines cannot start with blank characters, such as non-breaking spaces.",
+		"ErrorMalformedDueDate",
+		"Malformed due date",
+		"The value of the highlighted due tag is not a valid date.",
+		"Use the form YYYY-MM-DD, e.g.: #due=2024-05-01",
 	}
 }
 
-func ErrorMalformedEntry() HumanError {
+func ErrorMalformedPriority() HumanError {
 	return HumanError{
-		"ErrorMalformedEntry",
-		"Malformed entry",
-		"Please review the syntax of the entry. " +
-			"It must start with a duration or a time range. " +
-			"Valid examples would be: 3h20m or 8:00-10:00 or 8:00-? " +
-			"or <23:00-6:00 or 18:00-0:30>",
+		"ErrorMalformedPriority",
+		"Malformed priority",
+		"The value of the highlighted priority tag is not a valid priority.",
+		"Use a single letter from A (highest) to Z (lowest), e.g.: #prio=A",
 	}
 }
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"ktask/ktask"
+	"strings"
+)
+
+// HTMLRenderer renders the stages as side-by-side columns with a card for
+// each entry. The result is a single HTML file without external assets, which
+// contains a text filter for the cards.
+type HTMLRenderer struct {
+	Title string
+}
+
+func NewHTMLRenderer(title string) HTMLRenderer {
+	return HTMLRenderer{Title: title}
+}
+
+type htmlTag struct {
+	Text   string
+	Colour template.CSS
+}
+
+type htmlCard struct {
+	Title      string
+	Tags       []htmlTag
+	Notes      []string
+	CreatedAt  string
+	ModifiedAt string
+	Due        string
+	Filter     string
+}
+
+type htmlColumn struct {
+	Stage string
+	Cards []htmlCard
+}
+
+func (h HTMLRenderer) Render(rs ...ktask.Record) string {
+	var columns []htmlColumn
+	for _, r := range rs {
+		c := htmlColumn{Stage: string(r.Stage())}
+		for _, e := range r.Entries() {
+			card := htmlCard{
+				Title:      strings.Join(e.Name().WithoutTags(), " "),
+				Notes:      e.Notes(),
+				CreatedAt:  e.CreatedAt().Format("2006-01-02"),
+				ModifiedAt: e.ModifiedAt().Format("2006-01-02"),
+			}
+			if due, ok := e.Due(); ok {
+				card.Due = due.Format("2006-01-02")
+			}
+			for _, t := range e.Name().Tags().All() {
+				card.Tags = append(card.Tags, htmlTag{Text: t.ToString(), Colour: tagColour(t.Name())})
+			}
+			card.Filter = strings.ToLower(strings.Join(append(e.Name().Lines(), e.Notes()...), " "))
+			c.Cards = append(c.Cards, card)
+		}
+		columns = append(columns, c)
+	}
+
+	var b strings.Builder
+	err := htmlTemplate.Execute(&b, struct {
+		Title   string
+		Columns []htmlColumn
+	}{h.Title, columns})
+	if err != nil {
+		// The template is static and only gets strings, so this can't happen.
+		panic(err)
+	}
+	return b.String()
+}
+
+// tagColour derives the colour of a tag chip from the name of the tag, so
+// that tags with the same name (but different values) share the colour.
+func tagColour(name string) template.CSS {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(name)))
+	return template.CSS(fmt.Sprintf("hsl(%d, 65%%, 80%%)", h.Sum32()%360))
+}
+
+var htmlTemplate = template.Must(template.New("board").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; background: #f4f5f7; color: #172b4d; }
+h1 { font-size: 1.4em; }
+#filter { padding: .4em; width: 20em; max-width: 100%; margin-bottom: 1em; }
+.board { display: flex; gap: 1em; align-items: flex-start; overflow-x: auto; }
+.column { flex: 1 0 16em; background: #ebecf0; border-radius: 6px; padding: .5em; }
+.column h2 { font-size: 1.1em; margin: .2em .2em .6em; }
+.count { color: #5e6c84; font-weight: normal; }
+.card { background: #fff; border-radius: 4px; padding: .5em; margin-bottom: .5em; box-shadow: 0 1px 1px rgba(0,0,0,.2); }
+.title { font-weight: bold; }
+.tags { margin: .3em 0; }
+.tag { display: inline-block; border-radius: 1em; padding: 0 .5em; margin: 0 .2em .2em 0; font-size: .8em; font-family: monospace; }
+.notes { margin: .3em 0; padding-left: 1.2em; font-size: .9em; }
+.dates { color: #5e6c84; font-size: .8em; }
+.due { color: #bf2600; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<input id="filter" type="search" placeholder="Filter…" autofocus>
+<div class="board">
+{{- range .Columns}}
+<section class="column">
+<h2>{{.Stage}} <span class="count">{{len .Cards}}</span></h2>
+{{- range .Cards}}
+<article class="card" data-filter="{{.Filter}}">
+<div class="title">{{.Title}}</div>
+{{- if .Tags}}
+<div class="tags">{{range .Tags}}<span class="tag" style="background: {{.Colour}}">{{.Text}}</span>{{end}}</div>
+{{- end}}
+{{- if .Notes}}
+<ul class="notes">{{range .Notes}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+<div class="dates">created {{.CreatedAt}}, modified {{.ModifiedAt}}{{if .Due}}, <span class="due">due {{.Due}}</span>{{end}}</div>
+</article>
+{{- end}}
+</section>
+{{- end}}
+</div>
+<script>
+document.getElementById("filter").addEventListener("input", function (ev) {
+  var q = ev.target.value.toLowerCase().trim();
+  document.querySelectorAll(".column").forEach(function (col) {
+    var shown = 0;
+    col.querySelectorAll(".card").forEach(function (card) {
+      var match = card.dataset.filter.indexOf(q) >= 0;
+      card.classList.toggle("hidden", !match);
+      if (match) shown++;
+    });
+    col.querySelector(".count").textContent = shown;
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,131 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ktask/ktask"
+	"strings"
+	"time"
+)
+
+// FromJson reads records from their JSON representation as written by ToJson.
+// Only `records` is read. The name of an entry takes precedence, if it is
+// missing, it is made up of the title and the tags. Only the first line of the
+// name is the name, further lines (e.g. of older exports) become notes. The due date and the
+// priority are added as tags, unless the entry has such tags already.
+func FromJson(data []byte) ([]ktask.Record, error) {
+	var envelop Envelop
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&envelop); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if envelop.Errors != nil && envelop.Records == nil {
+		return nil, errors.New("the JSON contains errors instead of records")
+	}
+
+	var rs []ktask.Record
+	for _, rv := range envelop.Records {
+		stage := ktask.Stage(rv.Stage)
+		if err := stage.Valid(); err != nil {
+			return nil, fmt.Errorf("invalid stage %q", rv.Stage)
+		}
+		r := ktask.NewRecord(stage)
+		for i, ev := range rv.Entries {
+			e, err := fromEntryView(ev, i)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d of stage %q: %w", i+1, rv.Stage, err)
+			}
+			r.AppendEntry(e)
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func fromEntryView(ev EntryView, index int) (ktask.Entry, error) {
+	createdAt, err := time.Parse(dateFormat, ev.CreatedAt)
+	if err != nil {
+		return ktask.Entry{}, fmt.Errorf("invalid created_at %q, expected YYYY-MM-DD", ev.CreatedAt)
+	}
+	modifiedAt := createdAt
+	if ev.ModifiedAt != "" {
+		if modifiedAt, err = time.Parse(dateFormat, ev.ModifiedAt); err != nil {
+			return ktask.Entry{}, fmt.Errorf("invalid modified_at %q, expected YYYY-MM-DD", ev.ModifiedAt)
+		}
+	}
+
+	var tags []ktask.Tag
+	for _, tv := range ev.Tags {
+		t, err := ktask.NewTag(tv.Name, tv.Value)
+		if err != nil {
+			return ktask.Entry{}, fmt.Errorf("invalid tag %q=%q", tv.Name, tv.Value)
+		}
+		tags = append(tags, t)
+	}
+	if ev.Due != "" {
+		if _, err := time.Parse(dateFormat, ev.Due); err != nil {
+			return ktask.Entry{}, fmt.Errorf("invalid due %q, expected YYYY-MM-DD", ev.Due)
+		}
+		tags = append(tags, ktask.NewTagOrPanic(ktask.DueTagName, ev.Due))
+	}
+	if ev.Priority != "" {
+		p, err := ktask.NewPriority(ev.Priority)
+		if err != nil {
+			return ktask.Entry{}, fmt.Errorf("invalid priority %q, expected a letter from A to Z", ev.Priority)
+		}
+		tags = append(tags, ktask.NewTagOrPanic(ktask.PrioTagName, p.ToString()))
+	}
+
+	name := ktask.Name(ev.Name)
+	if len(name) == 0 {
+		if ev.Title == "" {
+			return ktask.Entry{}, errors.New("neither name nor title given")
+		}
+		name = ktask.Name{ev.Title}
+	}
+	if _, err := ktask.NewName(name...); err != nil {
+		return ktask.Entry{}, errors.New("the lines of the name must not contain line breaks and except for the first one must not be blank")
+	}
+	for _, n := range ev.Notes {
+		if strings.ContainsAny(n, "\r\n") {
+			return ktask.Entry{}, fmt.Errorf("invalid note %q, the notes must not contain line breaks", n)
+		}
+	}
+	// the lines following the name are read as notes
+	var notes []string
+	notes = append(append(notes, name[1:]...), ev.Notes...)
+	name, id := ktask.SplitID(name[:1])
+	var missing []ktask.Tag
+	for _, t := range tags {
+		// tags with the same name (e.g. the due date) are not added twice
+		if t.Name() != ktask.IdTagName && !name.Tags().Contains(t) && !hasTagNamed(name, t.Name()) {
+			missing = append(missing, t)
+		}
+	}
+	name = name.WithTags(missing...)
+	if ev.ID != "" {
+		if t, err := ktask.NewTagFromString(ktask.IdTagName + "=" + ev.ID); err != nil || t.Value() != ev.ID {
+			return ktask.Entry{}, fmt.Errorf("invalid id %q", ev.ID)
+		}
+		id = ev.ID
+	}
+
+	e := ktask.NewEntry(name, createdAt, modifiedAt, index)
+	e.SetID(id)
+	e.SetNotes(notes)
+	return e, nil
+}
+
+// hasTagNamed checks whether the name contains a tag with the given name and
+// a value, e.g. #due=2024-01-02 for due.
+func hasTagNamed(name ktask.Name, tagName string) bool {
+	for _, t := range name.Tags().All() {
+		if t.Name() == tagName && t.Value() != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package json
+
+import (
+	"ktask/ktask"
+	"ktask/ktask/parser"
+	"testing"
+
+	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonRoundTrip(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 #grocery buy milk #due=2024-01-05 #id=3fa2c1
+        two bottles
+    2024-01-02 2024-01-03 water plants #prio=A #where="living room"
+
+done
+    2023-12-01 2024-01-01 celebrate
+`
+	rs, _, errs := parser.NewSerialParser().Parse(text)
+	require.Nil(t, errs)
+
+	out := ToJson(rs, nil, true)
+	assert.Contains(t, out, `"name": "where",`)
+	assert.Contains(t, out, `"value": "living room"`)
+
+	imported, err := FromJson([]byte(out))
+	require.Nil(t, err)
+	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	assert.Equal(t, text, parser.SerialiseRecords(ser, imported...).ToString())
+}
+
+func TestJsonImportMultiLineName(t *testing.T) {
+	rs, err := FromJson([]byte(`{"records": [{"stage": "todo", "entries": [{
+		"name": ["buy milk #grocery", "#id=3fa2c1 two bottles"],
+		"notes": ["low fat"],
+		"created_at": "2024-01-02"
+	}]}]}`))
+	require.Nil(t, err)
+	e := rs[0].Entries()[0]
+	assert.Equal(t, ktask.Name{"buy milk #grocery"}, e.Name())
+	assert.Equal(t, []string{"#id=3fa2c1 two bottles", "low fat"}, e.Notes())
+	assert.Equal(t, "", e.ID())
+}
+
+func TestJsonImportWithoutName(t *testing.T) {
+	rs, err := FromJson([]byte(`{"records": [{"stage": "todo", "entries": [{
+		"title": "buy milk",
+		"tags": [{"name": "grocery", "value": ""}],
+		"created_at": "2024-01-02",
+		"due": "2024-01-05",
+		"priority": "b"
+	}]}]}`))
+	require.Nil(t, err)
+	require.Len(t, rs, 1)
+	require.Len(t, rs[0].Entries(), 1)
+	e := rs[0].Entries()[0]
+	assert.Equal(t, ktask.Name{"#grocery #due=2024-01-05 #prio=B buy milk"}, e.Name())
+	assert.Equal(t, e.CreatedAt(), e.ModifiedAt())
+
+	for _, invalid := range []string{
+		`{"records": [{"stage": "todo", "entries": [{"title": "x", "created_at": "2024-1-2"}]}]}`,
+		`{"records": [{"stage": "todo", "entries": [{"created_at": "2024-01-02"}]}]}`,
+		`{"records": [{"stage": " todo", "entries": []}]}`,
+		`{"records": [{"stage": "todo", "entries": [{"title": "x", "created_at": "2024-01-02", "tags": [{"name": "a b"}]}]}]}`,
+		`{"records": [], "unknown": 1}`,
+		// line breaks would end up as separate lines in the file
+		`{"records": [{"stage": "todo", "entries": [{"title": "x\n\ndone", "created_at": "2024-01-02"}]}]}`,
+		`{"records": [{"stage": "todo", "entries": [{"name": ["x", "y\rz"], "created_at": "2024-01-02"}]}]}`,
+		`{"records": [{"stage": "todo", "entries": [{"title": "x", "notes": ["n1\nzzz"], "created_at": "2024-01-02"}]}]}`,
+		`{"records": [{"stage": "todo", "entries": [{"title": "x", "created_at": "2024-01-02", "tags": [{"name": "a", "value": "b\nc"}]}]}]}`,
+		`{"records": [{"stage": "to\ndo", "entries": []}]}`,
+	} {
+		_, err := FromJson([]byte(invalid))
+		assert.NotNil(t, err, invalid)
+	}
+}
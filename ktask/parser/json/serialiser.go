@@ -0,0 +1,110 @@
+/*
+Package json contains the logic of serialising Record’s as JSON and reading
+them back. The structure is described by Envelop and the views it contains.
+*/
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"ktask/ktask"
+	"strings"
+
+	"github.com/jotaen/klog/klog/parser/txt"
+)
+
+const dateFormat = "2006-01-02"
+
+// ToJson serialises records into their JSON representation. The output
+// structure is Envelop at the top level.
+func ToJson(rs []ktask.Record, errs []txt.Error, prettyPrint bool) string {
+	envelop := func() Envelop {
+		if errs == nil {
+			return Envelop{
+				Records: toRecordViews(rs),
+				Errors:  nil,
+			}
+		} else {
+			return Envelop{
+				Records: nil,
+				Errors:  toErrorViews(errs),
+			}
+		}
+	}()
+	buffer := new(bytes.Buffer)
+	enc := json.NewEncoder(buffer)
+	if prettyPrint {
+		enc.SetIndent("", "  ")
+	}
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(&envelop)
+	if err != nil {
+		panic(err) // This should never happen
+	}
+	return strings.TrimRight(buffer.String(), "\n")
+}
+
+func toRecordViews(rs []ktask.Record) []RecordView {
+	result := []RecordView{}
+	for _, r := range rs {
+		result = append(result, RecordView{
+			Stage:   string(r.Stage()),
+			Entries: toEntryViews(r.Entries()),
+		})
+	}
+	return result
+}
+
+func toEntryViews(es []ktask.Entry) []EntryView {
+	result := []EntryView{}
+	for _, e := range es {
+		due := ""
+		if d, ok := e.Due(); ok {
+			due = d.Format(dateFormat)
+		}
+		prio := ""
+		if p, ok := e.Priority(); ok {
+			prio = p.ToString()
+		}
+		result = append(result, EntryView{
+			ID:         e.ID(),
+			Title:      e.Title(),
+			Name:       append([]string{}, e.Name().Lines()...),
+			Notes:      append([]string{}, e.Notes()...),
+			Tags:       toTagViews(e.Name().Tags().All()),
+			CreatedAt:  e.CreatedAt().Format(dateFormat),
+			ModifiedAt: e.ModifiedAt().Format(dateFormat),
+			Due:        due,
+			Priority:   prio,
+		})
+	}
+	return result
+}
+
+func toTagViews(ts []ktask.Tag) []TagView {
+	result := []TagView{}
+	for _, t := range ts {
+		result = append(result, TagView{t.Name(), t.Value()})
+	}
+	return result
+}
+
+func toErrorViews(errs []txt.Error) []ErrorView {
+	var result []ErrorView
+	for _, e := range errs {
+		fix := ""
+		if f, ok := e.(ktask.Fixable); ok {
+			fix = f.Fix()
+		}
+		result = append(result, ErrorView{
+			Line:    e.LineNumber(),
+			Column:  e.Column(),
+			Length:  e.Length(),
+			Title:   e.Title(),
+			Details: e.Details(),
+			Fix:     fix,
+			File:    e.Origin(),
+		})
+	}
+	return result
+}
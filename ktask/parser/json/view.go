@@ -0,0 +1,65 @@
+package json
+
+// Envelop is the top level data structure of the JSON output.
+// It contains two nodes, `records` and `errors`, one of which is always `null`.
+type Envelop struct {
+	Records []RecordView `json:"records"`
+	Errors  []ErrorView  `json:"errors"`
+}
+
+// RecordView is the JSON representation of a record, i.e. a stage.
+type RecordView struct {
+	Stage   string      `json:"stage"`
+	Entries []EntryView `json:"entries"`
+}
+
+// EntryView is the JSON representation of an entry.
+type EntryView struct {
+	// ID is the stable identifier of the entry, it is empty if the entry
+	// doesn't have one.
+	ID string `json:"id"`
+
+	// Title is the name of the entry without the first tag (the project).
+	Title string `json:"title"`
+
+	// Name contains the lines of the name as they appear in the file.
+	Name []string `json:"name"`
+
+	// Notes contains the lines of the notes of the entry.
+	Notes []string `json:"notes"`
+
+	// Tags is a list of all tags that the entry name contains. The names are
+	// lower case, the value is empty for tags without value. (It used to be a
+	// list of the tags as written, e.g. "#due=2024-02-05".)
+	Tags []TagView `json:"tags"`
+
+	// CreatedAt and ModifiedAt are dates in the form YYYY-MM-DD.
+	CreatedAt  string `json:"created_at"`
+	ModifiedAt string `json:"modified_at"`
+
+	// Due is the due date of the entry, it is empty if the entry doesn't have
+	// one.
+	Due string `json:"due"`
+
+	// Priority is the priority of the entry (A-Z), it is empty if the entry
+	// doesn't have one.
+	Priority string `json:"priority"`
+}
+
+// TagView is the JSON representation of a tag.
+type TagView struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ErrorView is the JSON representation of a parsing error.
+type ErrorView struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Length  int    `json:"length"`
+	Title   string `json:"title"`
+	Details string `json:"details"`
+	// Fix is a suggestion how to resolve the error, it may be empty.
+	Fix  string `json:"fix"`
+	File string `json:"file"`
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"ktask/ktask"
+	"strconv"
+	"strings"
+
+	"github.com/jotaen/klog/klog/parser/txt"
+)
+
+// quarantinedBlock is a block in which lines which couldn't be parsed were
+// replaced by comments. raw maps these comments back to the original lines.
+type quarantinedBlock struct {
+	txt.Block
+	raw map[string]txt.Line
+}
+
+// ParseLenient parses the text like the parser with the declared stages, but
+// instead of failing, lines which can't be parsed are quarantined: they are
+// kept verbatim as comments (see ktask.QuarantineLine) and written back
+// unchanged by the document. If the headline of a stage is broken, the whole
+// stage is quarantined. The errors are returned along with the records. If
+// nothing of the text can be parsed, only the errors are returned.
+func ParseLenient(text string, stages ktask.Stages) ([]ktask.Record, []txt.Block, []txt.Error) {
+	p := NewSerialParserWithStages(stages)
+	lines := strings.SplitAfter(text, "\n")
+	raw := map[string]txt.Line{}
+	quarantine := func(i int) bool {
+		text, ending := splitEnding(lines[i])
+		if _, ok := raw[text]; ok {
+			return false
+		}
+		marker := "//" + ktask.QuarantineLine(strconv.Itoa(i))
+		raw[marker] = txt.Line{Text: text, LineEnding: ending}
+		lines[i] = marker + ending
+		return true
+	}
+
+	var problems []txt.Error
+	for {
+		rs, bs, errs := p.Parse(strings.Join(lines, ""))
+		if errs == nil {
+			if (len(rs) == 0 || IsCommentRecord(rs[0]) && len(rs) == 1) && len(problems) > 0 {
+				return nil, nil, problems
+			}
+			for i, r := range rs {
+				r.SetComments(unquarantine(raw, r.Comments()))
+				r.SetTrailingComments(unquarantine(raw, r.TrailingComments()))
+				es := r.Entries()
+				for j := range es {
+					es[j].SetComments(unquarantine(raw, es[j].Comments()))
+				}
+				bs[i] = quarantinedBlock{bs[i], raw}
+			}
+			return rs, bs, problems
+		}
+
+		progress := false
+		for _, e := range errs {
+			i := e.LineNumber() - 1
+			if i >= len(lines) {
+				continue
+			}
+			end := i + 1
+			text, _ := splitEnding(lines[i])
+			indentation := len(text) - len(strings.TrimLeft(text, " \t"))
+			if isHeadline(lines, i) || indentation == 0 {
+				// the entries can't be assigned to a stage
+				for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+					end++
+				}
+			} else {
+				// the notes of an entry would be assigned to the previous one
+				for end < len(lines) && len(lines[end])-len(strings.TrimLeft(lines[end], " \t")) > indentation {
+					end++
+				}
+			}
+			for j := i; j < end; j++ {
+				progress = quarantine(j) || progress
+			}
+		}
+		problems = append(problems, errs...)
+		if !progress {
+			return nil, nil, problems
+		}
+	}
+}
+
+// unquarantine replaces the comments which replaced quarantined lines with
+// the lines.
+func unquarantine(raw map[string]txt.Line, comments []string) []string {
+	for i, c := range comments {
+		if l, ok := raw[c]; ok {
+			comments[i] = ktask.QuarantineLine(l.Text)
+		}
+	}
+	return comments
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"strings"
+)
+
+// Renderer turns records into a document of another format, e.g. for
+// reports. As opposed to a Serialiser, the result can't be parsed again.
+type Renderer interface {
+	Render(rs ...ktask.Record) string
+}
+
+// MarkdownRenderer renders the stages as headings and their entries as
+// checkbox lists (or tables), the tags are rendered as inline code. Entries
+// of DoneStage are checked.
+type MarkdownRenderer struct {
+	// Table renders the entries of a stage as table instead of a list.
+	Table     bool
+	DoneStage ktask.Stage
+}
+
+func NewMarkdownRenderer(table bool, done ktask.Stage) MarkdownRenderer {
+	return MarkdownRenderer{
+		Table:     table,
+		DoneStage: done,
+	}
+}
+
+func (m MarkdownRenderer) Render(rs ...ktask.Record) string {
+	var b strings.Builder
+	for i, r := range rs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n\n", markdownEscape(string(r.Stage())))
+		es := r.Entries()
+		switch {
+		case len(es) == 0:
+			b.WriteString("_No entries._\n")
+		case m.Table:
+			m.renderTable(&b, es)
+		default:
+			m.renderList(&b, r.Stage(), es)
+		}
+	}
+	return b.String()
+}
+
+func (m MarkdownRenderer) renderList(b *strings.Builder, stage ktask.Stage, es []ktask.Entry) {
+	check := " "
+	if stage == m.DoneStage {
+		check = "x"
+	}
+	for _, e := range es {
+		fmt.Fprintf(b, "- [%s] %s", check, markdownTitle(e))
+		if tags := markdownTags(e); tags != "" {
+			b.WriteString(" " + tags)
+		}
+		fmt.Fprintf(b, " (created %s, modified %s)\n", e.CreatedAt().Format("2006-01-02"), e.ModifiedAt().Format("2006-01-02"))
+		for _, n := range e.Notes() {
+			b.WriteString("  - " + markdownEscape(n) + "\n")
+		}
+	}
+}
+
+func (m MarkdownRenderer) renderTable(b *strings.Builder, es []ktask.Entry) {
+	b.WriteString("| Title | Tags | Created | Modified | Notes |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	cell := func(s string) string {
+		return strings.ReplaceAll(s, "|", `\|`)
+	}
+	for _, e := range es {
+		var notes []string
+		for _, n := range e.Notes() {
+			notes = append(notes, markdownEscape(n))
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n",
+			cell(markdownTitle(e)),
+			cell(markdownTags(e)),
+			e.CreatedAt().Format("2006-01-02"),
+			e.ModifiedAt().Format("2006-01-02"),
+			cell(strings.Join(notes, "<br>")),
+		)
+	}
+}
+
+// markdownTitle returns the name of the entry without the tags in one line.
+func markdownTitle(e ktask.Entry) string {
+	return markdownEscape(strings.Join(e.Name().WithoutTags(), " "))
+}
+
+// markdownEscaper escapes the characters which would otherwise format the
+// text, e.g. as emphasis, link or heading.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "#", `\#`,
+	"[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;",
+)
+
+// markdownEscape escapes text, so that it is shown as is.
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownTags returns the tags of the entry as inline code. Tags containing
+// a backtick are enclosed in double backticks.
+func markdownTags(e ktask.Entry) string {
+	var tags []string
+	for _, t := range e.Name().Tags().ToStrings() {
+		if strings.Contains(t, "`") {
+			tags = append(tags, "`` "+t+" ``")
+		} else {
+			tags = append(tags, "`"+t+"`")
+		}
+	}
+	return strings.Join(tags, " ")
+}
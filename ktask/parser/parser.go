@@ -5,12 +5,17 @@ package parser
 
 import (
 	"ktask/ktask"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/jotaen/klog/klog/parser/txt"
 )
 
-func parse(block txt.Block) (ktask.Record, []txt.Error) {
+// parse parses one block into a record. If stages were declared, the headline
+// must match one of them.
+func parse(block txt.Block, stages ktask.Stages) (ktask.Record, []txt.Error) {
 	lines, initialLineOffset, _ := block.SignificantLines()
 	initialLineCount := len(lines) // Capture current value
 	nr := func(lines []txt.Line) int {
@@ -18,6 +23,20 @@ func parse(block txt.Block) (ktask.Record, []txt.Error) {
 	}
 	var errs []txt.Error
 
+	// ========== COMMENTS ==========
+	var comments []string
+	for len(lines) > 0 && isComment(lines[0]) {
+		comments = append(comments, commentText(lines[0]))
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		// The block only consists of comments, the parser attaches them to the
+		// next record.
+		r := ktask.NewRecord("")
+		r.SetComments(comments)
+		return r, nil
+	}
+
 	// ========== HEADLINE ==========
 	record := func() ktask.Record {
 		headline := txt.NewParseable(lines[0], 0)
@@ -29,15 +48,37 @@ func parse(block txt.Block) (ktask.Record, []txt.Error) {
 		}
 
 		// Parse the stage
-		stageText, _ := headline.PeekUntil(func(_ rune) bool { return false }) // Move forward until end of line
-		rStage := ktask.Stage(stageText.ToString())
-		sErr := rStage.Valid()
+		rStage, sErr := func() (ktask.Stage, txt.Error) {
+			stageText, _ := headline.PeekUntil(func(_ rune) bool { return false }) // Move forward until end of line
+			if len(stages) == 0 {
+				s := ktask.Stage(strings.TrimRight(stageText.ToString(), " \t"))
+				if s.Valid() != nil {
+					return "", ErrorInvalidStage().New(block, nr(lines), headline.PointerPosition, stageText.Length())
+				}
+				headline.Advance(len([]rune(string(s))))
+				return s, nil
+			}
+			// Match the longest declared stage, so that e.g. `in progress` wins over `in`.
+			var match ktask.Stage
+			for _, s := range stages {
+				if len(s) <= len(match) || !strings.HasPrefix(stageText.ToString(), string(s)) {
+					continue
+				}
+				rest := strings.TrimPrefix(stageText.ToString(), string(s))
+				if rest == "" || txt.IsSpaceOrTab([]rune(rest)[0]) {
+					match = s
+				}
+			}
+			if match == "" {
+				return "", ErrorInvalidStage().New(block, nr(lines), headline.PointerPosition, stageText.Length())
+			}
+			headline.Advance(len([]rune(string(match))))
+			return match, nil
+		}()
 		if sErr != nil {
-			errs = append(errs, ErrorInvalidStage().New(block, nr(lines), headline.PointerPosition, stageText.Length()))
+			errs = append(errs, sErr)
 			return nil
 		}
-		headline.Advance(stageText.Length())
-		headline.SkipWhile(txt.IsSpaceOrTab)
 		r := ktask.NewRecord(rStage)
 
 		// Make sure there is no other text left in the headline.
@@ -55,17 +96,29 @@ func parse(block txt.Block) (ktask.Record, []txt.Error) {
 		dummyStage := ktask.Stage("ToDo")
 		record = ktask.NewRecord(dummyStage)
 	}
+	record.SetComments(comments)
 
 	var indentator *txt.Indentator
 
 	// ========== ENTRIES ==========
 	index := int(-1)
+	// comments which weren't attached to an entry yet
+	var pending []string
 	for len(lines) > 0 {
 		l := lines[0]
+		if isComment(l) {
+			pending = append(pending, commentText(l))
+			lines = lines[1:]
+			continue
+		}
+		entryComments := pending
+		pending = nil
+
 		indentator = txt.NewIndentator(txt.Indentations, l)
 		if indentator == nil {
-			// We should never make it here if the indentation could not be determined.
-			panic("Could not detect indentation")
+			// E.g. another headline without a blank line in between.
+			errs = append(errs, ErrorIllegalIndentation().New(block, nr(lines), 0, len(l.Text)))
+			break
 		}
 
 		// Check for correct indentation.
@@ -76,75 +129,89 @@ func parse(block txt.Block) (ktask.Record, []txt.Error) {
 		}
 
 		// Parse entry value.
-		createEntry, evErr := func() (func(ktask.Name, int) txt.Error, txt.Error) {
-			// Try to interpret the entry value as stage.
+		createEntry, evErr := func() (func(ktask.Name, []string, []string, int) txt.Error, txt.Error) {
+			// The entry starts with the creation and modification date.
 			createdAtCandidate, _ := entry.PeekUntil(txt.IsSpaceOrTab)
 			createdAt, dErr := time.Parse("2006-01-02", createdAtCandidate.ToString())
 			if dErr != nil {
-				return nil, ErrorMalformedEntry().New(block, nr(lines), entry.PointerPosition, createdAtCandidate.Length())
+				return nil, ErrorMalformedCreatedAt().New(block, nr(lines), entry.PointerPosition, createdAtCandidate.Length())
 			}
 			entry.Advance(createdAtCandidate.Length())
 			entry.SkipWhile(txt.IsSpaceOrTab)
 
 			modifiedAtCandidate, _ := entry.PeekUntil(txt.IsSpaceOrTab)
 			if modifiedAtCandidate.Length() == 0 {
-				return nil, ErrorMalformedEntry().New(block, nr(lines), entry.PointerPosition, 1)
+				return nil, ErrorMalformedModifiedAt().New(block, nr(lines), entry.PointerPosition, 1)
 			}
 			modifiedAt, dErr := time.Parse("2006-01-02", modifiedAtCandidate.ToString())
 			if dErr != nil {
-				return nil, ErrorMalformedEntry().New(block, nr(lines), entry.PointerPosition, createdAtCandidate.Length())
+				return nil, ErrorMalformedModifiedAt().New(block, nr(lines), entry.PointerPosition, modifiedAtCandidate.Length())
 			}
 			entry.Advance(modifiedAtCandidate.Length())
 
-			return func(n ktask.Name, i int) txt.Error {
-				record.AddEntry(n, createdAt, modifiedAt, i)
+			return func(n ktask.Name, notes []string, comments []string, i int) txt.Error {
+				n, id := ktask.SplitID(n)
+				e := ktask.NewEntry(n, createdAt, modifiedAt, i)
+				e.SetID(id)
+				e.SetNotes(notes)
+				e.SetComments(comments)
+				record.AppendEntry(e)
 				return nil
 			}, nil
 		}()
 		lines = lines[1:]
 		index++
 
-		// Check for error while parsing the entry value.
+		// Check for error while parsing the entry value. The notes of the
+		// entry are skipped, otherwise they would be reported as well.
 		if evErr != nil {
 			errs = append(errs, evErr)
+			for len(lines) > 0 && indentator.NewIndentedParseable(lines[0], 2) != nil {
+				lines = lines[1:]
+			}
 			continue
 		}
 
 		// Parse entry summary.
-		entryName, esErr := func() (ktask.Name, txt.Error) {
+		entryName, entryNotes, esErr := func() (ktask.Name, []string, txt.Error) {
 			var result ktask.Name
+			var notes []string
 
-			// Parse first line of entry summary.
-			if txt.IsSpaceOrTab(entry.Peek()) {
-				entry.Advance(1)
-				nameText := entry.Remainder()
-				firstLine, sErr := ktask.NewName(nameText.ToString())
-				if sErr != nil {
-					return nil, ErrorMalformedSummary().New(block, nr(lines), 0, nameText.Length())
-				}
-				result = firstLine
-			} else {
-				result, _ = ktask.NewName("")
-			}
+			// Parse the title, which is the rest of the first line.
+			// Errors in the title are reported after the notes were consumed,
+			// so that the notes aren't mistaken for entries.
+			entry.SkipWhile(txt.IsSpaceOrTab)
+			nameText := entry.Remainder()
+			tErr := checkTags(block, nr(lines)-1, nameText.PointerPosition, nameText.ToString())
+			result, _ = ktask.NewName(nameText.ToString())
 
-			// Parse subsequent lines of multiline name.
+			// Parse subsequent lines, which are the notes.
 			for len(lines) > 0 {
-				nextNameLine := indentator.NewIndentedParseable(lines[0], 2)
-				if nextNameLine == nil {
+				// Comments in between belong to the entry, if it continues
+				// after them.
+				j := 0
+				for j < len(lines) && isComment(lines[j]) {
+					j++
+				}
+				if j == len(lines) || indentator.NewIndentedParseable(lines[j], 2) == nil {
 					break
 				}
+				for _, c := range lines[:j] {
+					entryComments = append(entryComments, commentText(c))
+				}
+				lines = lines[j:]
+				nextNoteLine := indentator.NewIndentedParseable(lines[0], 2)
 				lines = lines[1:]
-				additionalText, _ := nextNameLine.PeekUntil(func(_ rune) bool {
+				noteText, _ := nextNoteLine.PeekUntil(func(_ rune) bool {
 					return false // Move forward until end of line
 				})
-				newEntrySummary, sErr := ktask.NewName(append(result, additionalText.ToString())...)
-				if sErr != nil {
-					return nil, ErrorMalformedSummary().New(block, nr(lines), 0, nextNameLine.Length())
+				if _, sErr := ktask.NewName("", noteText.ToString()); sErr != nil {
+					return nil, nil, ErrorMalformedNotes().New(block, nr(lines)-1, 0, nextNoteLine.Length())
 				}
-				result = newEntrySummary
+				notes = append(notes, noteText.ToString())
 			}
 
-			return result, nil
+			return result, notes, tErr
 		}()
 
 		// Check for error while parsing the entry summary.
@@ -154,14 +221,107 @@ func parse(block txt.Block) (ktask.Record, []txt.Error) {
 		}
 
 		// Check for error when eventually applying the entry.
-		eErr := createEntry(entryName, index)
+		eErr := createEntry(entryName, entryNotes, entryComments, index)
 		if eErr != nil {
 			errs = append(errs, eErr)
 		}
 	}
+	record.SetTrailingComments(pending)
 
 	if len(errs) > 0 {
 		return nil, errs
 	}
 	return record, nil
 }
+
+// tagStartPattern matches the words which start with a # and thus are meant
+// to be tags. A # followed by a blank (e.g. "# of items") isn't a tag.
+var tagStartPattern = regexp.MustCompile(`(^|[ \t])#[^ \t]+`)
+
+// isComment checks whether the line is a comment, i.e. it starts with `//` or
+// `;` (after an optional indentation).
+func isComment(l txt.Line) bool {
+	text := strings.TrimLeft(l.Text, " \t")
+	return strings.HasPrefix(text, "//") || strings.HasPrefix(text, ";")
+}
+
+// commentText returns the comment without the surrounding whitespace.
+func commentText(l txt.Line) string {
+	return strings.TrimSpace(l.Text)
+}
+
+// stagesPattern matches the comment which declares the stages of a board, e.g.
+// `// stages: todo, review, done`.
+var stagesPattern = regexp.MustCompile(`^(//|;)[ \t]*stages:`)
+
+// DeclaredStages returns the stages declared in the comments at the head of
+// the text, i.e. before the first stage. It returns nil if the text doesn't
+// declare any stages.
+func DeclaredStages(text string) (ktask.Stages, []txt.Error) {
+	lineCount := 0
+	for {
+		b, consumed := txt.ParseBlock(text, lineCount)
+		if b == nil {
+			return nil, nil
+		}
+		for i, l := range b.Lines() {
+			if l.IsBlank() {
+				continue
+			}
+			if !isComment(l) {
+				return nil, nil
+			}
+			comment := commentText(l)
+			loc := stagesPattern.FindStringIndex(comment)
+			if loc == nil {
+				continue
+			}
+			stages, err := ktask.NewStages(strings.Split(comment[loc[1]:], ",")...)
+			if err != nil {
+				start := utf8.RuneCountInString(l.Text[:strings.Index(l.Text, comment)])
+				return nil, []txt.Error{ErrorInvalidStageDeclaration().New(b, i, start, utf8.RuneCountInString(comment))}
+			}
+			return stages, nil
+		}
+		text = text[consumed:]
+		lineCount += len(b.Lines())
+	}
+}
+
+// checkTags validates the tags of the text as well as the values of the tags
+// which have a special meaning, such as the due date or the priority. The
+// offset is the position of the text in the line.
+func checkTags(block txt.Block, line int, offset int, text string) txt.Error {
+	// A word starting with # must be a complete tag, e.g. #a="b misses the
+	// closing quote.
+	for _, m := range tagStartPattern.FindAllStringIndex(text, -1) {
+		word := strings.TrimLeft(text[m[0]:m[1]], " \t")
+		// quoted values may contain blanks, so the tag may exceed the word
+		rest := text[m[1]-len(word):]
+		if loc := ktask.HashTagPattern.FindStringIndex(rest); loc != nil && loc[0] == 0 &&
+			!strings.ContainsAny(rest[loc[1]:loc[1]+min(1, len(rest)-loc[1])], `="'`) {
+			continue
+		}
+		start := offset + utf8.RuneCountInString(text[:m[1]-len(word)])
+		return ErrorInvalidTag().New(block, line, start, utf8.RuneCountInString(word))
+	}
+	for _, m := range ktask.HashTagPattern.FindAllStringIndex(text, -1) {
+		tag, err := ktask.NewTagFromString(text[m[0]:m[1]])
+		if err != nil {
+			continue
+		}
+		start := offset + utf8.RuneCountInString(text[:m[0]])
+		length := utf8.RuneCountInString(text[m[0]:m[1]])
+		switch tag.Name() {
+		case ktask.DueTagName:
+			if _, dErr := time.Parse("2006-01-02", tag.Value()); dErr != nil {
+				return ErrorMalformedDueDate().New(block, line, start, length)
+			}
+		case ktask.PrioTagName:
+			if _, pErr := ktask.NewPriority(tag.Value()); pErr != nil {
+				return ErrorMalformedPriority().New(block, line, start, length)
+			}
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -72,7 +73,8 @@ todo
 
 		assert.Equal(t, time.Date(1970, 8, 27, 0, 0, 0, 0, time.UTC), r.Entries()[1].CreatedAt())
 		assert.Equal(t, time.Date(1970, 8, 26, 0, 0, 0, 0, time.UTC), r.Entries()[1].ModifiedAt())
-		assert.Equal(t, ktask.Name([]string{"needs to be done with", "multiline summary"}), r.Entries()[1].Name())
+		assert.Equal(t, ktask.Name([]string{"needs to be done with"}), r.Entries()[1].Name())
+		assert.Equal(t, []string{"multiline summary"}, r.Entries()[1].Notes())
 		assert.Equal(t, int(1), r.Entries()[1].Index())
 	}
 }
@@ -110,6 +112,213 @@ func TestParseWindowsAndUnixLineEndings(t *testing.T) {
 	}
 }
 
+func TestParseArbitraryStages(t *testing.T) {
+	text := "review\n\nblocked by others  \n    2024-01-02 2024-01-03 ask for access"
+	for _, p := range parsers {
+		rs, _, errs := p.Parse(text)
+		require.Nil(t, errs)
+		require.Len(t, rs, 2)
+
+		assert.Equal(t, ktask.Stage("review"), rs[0].Stage())
+		assert.Equal(t, ktask.Stage("blocked by others"), rs[1].Stage())
+		assert.Len(t, rs[1].Entries(), 1)
+	}
+}
+
+func TestParseDeclaredStages(t *testing.T) {
+	stages, err := ktask.NewStages("todo", "in", "in progress", "done")
+	require.Nil(t, err)
+	p := NewSerialParserWithStages(stages)
+
+	rs, _, errs := p.Parse("in progress\n\ndone\n\nin")
+	require.Nil(t, errs)
+	require.Len(t, rs, 3)
+	assert.Equal(t, ktask.InProgress, rs[0].Stage())
+	assert.Equal(t, ktask.Done, rs[1].Stage())
+	assert.Equal(t, ktask.Stage("in"), rs[2].Stage())
+
+	rs, _, errs = p.Parse("review\n\ntodo later")
+	require.Nil(t, rs)
+	require.Len(t, errs, 2)
+	assert.Equal(t, ErrorInvalidStage().toErrData(1, 0, 6), toErrData(errs[0]))
+	assert.Equal(t, ErrorUnrecognisedTextInHeadline().toErrData(3, 5, 5), toErrData(errs[1]))
+}
+
+func TestDeclaredStages(t *testing.T) {
+	for _, test := range []struct {
+		text   string
+		expect ktask.Stages
+	}{
+		{"todo\n", nil},
+		{"// stages: todo, in progress, done\ntodo\n", ktask.Stages{"todo", "in progress", "done"}},
+		{"// my board\n\n  ;stages:todo,done\n\ntodo\n", ktask.Stages{"todo", "done"}},
+		// only the head of the file declares stages
+		{"todo\n    // stages: todo, done\n", nil},
+		{"todo\n\n// stages: todo, done\ndone\n", nil},
+		{"// the stages: todo, done\n", nil},
+	} {
+		stages, errs := DeclaredStages(test.text)
+		require.Nil(t, errs, test.text)
+		assert.Equal(t, test.expect, stages, test.text)
+	}
+
+	for _, invalid := range []string{"// stages:\n", "// stages: todo, , done\n", "\n// hi\n  // stages: todo, todo\n"} {
+		_, errs := DeclaredStages(invalid)
+		require.Len(t, errs, 1, invalid)
+		assert.Equal(t, "ErrorInvalidStageDeclaration", errs[0].Code())
+	}
+	_, errs := DeclaredStages("// my board\n\n  // stages: todo, todo\n")
+	assert.Equal(t, ErrorInvalidStageDeclaration().toErrData(3, 2, 21), toErrData(errs[0]))
+}
+
+func TestParseAndSerialiseEntryID(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 #grocery buy #id=3fa2c1 milk
+    2024-01-02 2024-01-03 no id
+`
+	for _, p := range parsers {
+		rs, _, errs := p.Parse(text)
+		require.Nil(t, errs)
+		require.Len(t, rs, 1)
+		require.Len(t, rs[0].Entries(), 2)
+
+		e := rs[0].Entries()[0]
+		assert.Equal(t, "3fa2c1", e.ID())
+		assert.Equal(t, ktask.Name([]string{"#grocery buy milk"}), e.Name())
+		assert.Equal(t, "", rs[0].Entries()[1].ID())
+
+		ser := NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+		assert.Equal(t, `todo
+    2024-01-02 2024-01-03 #grocery buy milk #id=3fa2c1
+    2024-01-02 2024-01-03 no id
+`, SerialiseRecords(ser, rs...).ToString())
+	}
+}
+
+func TestParseDueDate(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 #grocery buy milk #due=2024-01-05
+    2024-01-02 2024-01-03 no due date
+`
+	for _, p := range parsers {
+		rs, _, errs := p.Parse(text)
+		require.Nil(t, errs)
+		require.Len(t, rs[0].Entries(), 2)
+
+		due, ok := rs[0].Entries()[0].Due()
+		assert.True(t, ok)
+		assert.Equal(t, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), due)
+		_, ok = rs[0].Entries()[1].Due()
+		assert.False(t, ok)
+	}
+}
+
+func TestReportErrorForMalformedDueDate(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 buy milk
+        notes are not checked #due=tomorrow
+    2024-01-02 2024-01-03 buy milk #due=tomorrow
+`
+	for _, p := range parsers {
+		rs, _, errs := p.Parse(text)
+		require.Nil(t, rs)
+		require.Len(t, errs, 1)
+		assert.Equal(t, ErrorMalformedDueDate().toErrData(4, 35, 13), toErrData(errs[0]))
+	}
+}
+
+func TestReportErrorForMissingIndentation(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 buy milk
+done
+`
+	for _, p := range parsers {
+		rs, _, errs := p.Parse(text)
+		require.Nil(t, rs)
+		require.Len(t, errs, 1)
+		assert.Equal(t, ErrorIllegalIndentation().toErrData(3, 0, 4), toErrData(errs[0]))
+	}
+}
+
+func TestReportErrorsInEntries(t *testing.T) {
+	for _, test := range []struct {
+		text   string
+		expect errData
+	}{
+		{"todo\n    2024-1-02 2024-01-03 buy milk", ErrorMalformedCreatedAt().toErrData(2, 4, 9)},
+		{"todo\n    buy 2024-01-03 milk\n        notes", ErrorMalformedCreatedAt().toErrData(2, 4, 3)},
+		{"todo\n    2024-01-02 yesterday buy milk", ErrorMalformedModifiedAt().toErrData(2, 15, 9)},
+		{"todo\n    2024-01-02", ErrorMalformedModifiedAt().toErrData(2, 14, 1)},
+		{"todo\n    2024-01-02 2024-01-03 buy #a=\"b milk", ErrorInvalidTag().toErrData(2, 30, 5)},
+		{"todo\n    2024-01-02 2024-01-03 #=x milk", ErrorInvalidTag().toErrData(2, 26, 3)},
+	} {
+		for _, p := range parsers {
+			rs, _, errs := p.Parse(test.text)
+			require.Nil(t, rs, test.text)
+			require.Len(t, errs, 1, test.text)
+			assert.Equal(t, test.expect, toErrData(errs[0]), test.text)
+			assert.NotEmpty(t, errs[0].(ktask.Fixable).Fix())
+		}
+	}
+
+	_, _, errs := NewSerialParser().Parse("todo\n    2024-01-02 2024-01-03 learn C# and # of items")
+	assert.Nil(t, errs)
+
+	// quoted values may contain blanks
+	for _, title := range []string{
+		`#where="living room" tidy up`,
+		`tidy up #where="living room"`,
+		`say #what='"hi" to all'`,
+		`#a="" #b=''`,
+	} {
+		rs, _, errs := NewSerialParser().Parse("todo\n    2024-01-02 2024-01-03 " + title)
+		require.Nil(t, errs, title)
+		assert.Equal(t, ktask.Name{title}, rs[0].Entries()[0].Name())
+	}
+
+	// entries without title are valid, `ktask check` warns about them
+	rs, _, errs := NewSerialParser().Parse("todo\n    2024-01-02 2024-01-03  \n        notes")
+	require.Nil(t, errs)
+	assert.Equal(t, []string{"notes"}, rs[0].Entries()[0].Notes())
+}
+
+func TestParsePriority(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 #grocery buy milk #prio=b
+    2024-01-02 2024-01-03 no priority
+`
+	for _, p := range parsers {
+		rs, _, errs := p.Parse(text)
+		require.Nil(t, errs)
+		require.Len(t, rs[0].Entries(), 2)
+
+		prio, ok := rs[0].Entries()[0].Priority()
+		assert.True(t, ok)
+		assert.Equal(t, ktask.Priority('B'), prio)
+		_, ok = rs[0].Entries()[1].Priority()
+		assert.False(t, ok)
+	}
+
+	rs, _, errs := NewSerialParser().Parse("todo\n    2024-01-02 2024-01-03 #prio=high asap")
+	require.Nil(t, rs)
+	require.Len(t, errs, 1)
+	assert.Equal(t, ErrorMalformedPriority().toErrData(2, 26, 10), toErrData(errs[0]))
+}
+
+func TestSerialiseNotes(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 buy milk #grocery #id=3fa2c1
+        the one from the farm
+          not the cheap one
+`
+	rs, _, errs := NewSerialParser().Parse(text)
+	require.Nil(t, errs)
+	assert.Equal(t, []string{"the one from the farm", "  not the cheap one"}, rs[0].Entries()[0].Notes())
+
+	ser := NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	assert.Equal(t, text, SerialiseRecords(ser, rs...).ToString())
+}
+
 //
 // func TestParseUtf8Document(t *testing.T) {
 // 	text := `
@@ -477,3 +686,268 @@ func TestParseWindowsAndUnixLineEndings(t *testing.T) {
 // 		assert.Equal(t, ErrorInvalidDate().toErrData(17, 0, 10), toErrData(errs[3]))
 // 	}
 // }
+
+func TestDocumentKeepsOriginalText(t *testing.T) {
+	for _, text := range []string{
+		"todo\n    2024-01-02 2024-01-03 buy milk\n",
+		"\n\ntodo\n\t2024-01-02 2024-01-03 buy milk   \n\t\tnotes\n\n\n\ndone  \n  2024-01-01 2024-01-01 #id=abcdef done",
+		"todo\r\n  2024-01-02 2024-01-03 buy milk\r\n    notes\r\n\r\ndone\r\n",
+		"todo\r\n  2024-01-02 2024-01-03\r\n    buy milk\r\n\r\ndone\r\n",
+	} {
+		rs, bs, errs := NewSerialParser().Parse(text)
+		require.Nil(t, errs)
+		doc := NewDocument(rs, bs)
+		assert.Equal(t, text, doc.Serialise(NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false), rs...))
+	}
+}
+
+func TestDocumentOnlyChangesModifiedParts(t *testing.T) {
+	text := "todo\n\t2024-01-02 2024-01-03 buy milk\n\t2024-01-02 2024-01-03  water plants\n\ndone\n  2024-01-01 2024-01-01   celebrate"
+	rs, bs, errs := NewSerialParser().Parse(text)
+	require.Nil(t, errs)
+	doc := NewDocument(rs, bs)
+
+	moved := rs[0].RemoveEntry(0)
+	moved.SetName(ktask.Name{"buy oat milk"})
+	rs[1].AppendEntry(moved)
+	rs = append(rs, ktask.NewRecord("waiting"))
+
+	assert.Equal(t, "todo\n\t2024-01-02 2024-01-03  water plants\n\ndone\n  2024-01-01 2024-01-01   celebrate\n  2024-01-02 2024-01-03 buy oat milk\n\nwaiting\n",
+		doc.Serialise(NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false), rs...))
+}
+
+func TestDocumentKeepsLineEndings(t *testing.T) {
+	text := "todo\r\n    2024-01-02 2024-01-03 buy milk\r\n\r\ndone\r\n    2024-01-01 2024-01-01 celebrate"
+	rs, bs, errs := NewSerialParser().Parse(text)
+	require.Nil(t, errs)
+	doc := NewDocument(rs, bs)
+
+	rs[1].AppendEntry(ktask.NewEntry(ktask.Name{"water plants"}, rs[1].Entries()[0].CreatedAt(), rs[1].Entries()[0].CreatedAt(), 1))
+	rs = append(rs, ktask.NewRecord("waiting"))
+	assert.Equal(t, "todo\r\n    2024-01-02 2024-01-03 buy milk\r\n\r\ndone\r\n    2024-01-01 2024-01-01 celebrate\r\n    2024-01-01 2024-01-01 water plants\r\n\r\nwaiting\r\n",
+		doc.Serialise(NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false), rs...))
+}
+
+func TestParseComments(t *testing.T) {
+	text := `// the board of
+; my household
+
+todo
+    // before the entry
+    2024-01-02 2024-01-03 buy milk
+        ; between the lines
+        2x
+    2024-01-02 2024-01-03 water plants
+    // at the end
+
+// belongs to done
+done
+
+// at the end of the file
+`
+	for _, p := range parsers {
+		rs, _, errs := p.Parse(text)
+		require.Nil(t, errs)
+		require.Len(t, rs, 2)
+
+		assert.Equal(t, []string{"// the board of", "; my household"}, rs[0].Comments())
+		assert.Equal(t, []string{"// at the end"}, rs[0].TrailingComments())
+		es := rs[0].Entries()
+		require.Len(t, es, 2)
+		assert.Equal(t, []string{"// before the entry", "; between the lines"}, es[0].Comments())
+		assert.Equal(t, []string{"2x"}, es[0].Notes())
+		assert.Nil(t, es[1].Comments())
+
+		assert.Equal(t, []string{"// belongs to done"}, rs[1].Comments())
+		assert.Equal(t, []string{"// at the end of the file"}, rs[1].TrailingComments())
+	}
+}
+
+func TestSerialiseComments(t *testing.T) {
+	text := `// the board
+todo
+  // before the entry
+  2024-01-02 2024-01-03 buy milk
+    2x
+  // at the end
+`
+	rs, bs, errs := NewSerialParser().Parse(text)
+	require.Nil(t, errs)
+	ser := NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	assert.Equal(t, `// the board
+todo
+    // before the entry
+    2024-01-02 2024-01-03 buy milk
+        2x
+    // at the end
+`, SerialiseRecords(ser, rs...).ToString())
+	assert.Equal(t, text, NewDocument(rs, bs).Serialise(ser, rs...))
+}
+
+func TestSerialiseCommentsWithoutStages(t *testing.T) {
+	ser := NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	for _, text := range []string{
+		"// the board of\n; my household\n",
+		"// the board\n\n// of my household\n",
+	} {
+		for _, p := range parsers {
+			rs, bs, errs := p.Parse(text)
+			require.Nil(t, errs)
+			require.Len(t, rs, 1)
+			assert.True(t, IsCommentRecord(rs[0]))
+			assert.Equal(t, text, NewDocument(rs, bs).Serialise(ser, rs...))
+		}
+	}
+
+	rs, bs, errs := NewSerialParser().Parse("// the board\n")
+	require.Nil(t, errs)
+	assert.Equal(t, "// the board\n", SerialiseRecords(ser, rs...).ToString())
+	// the comments stay at the top, even if the record holding them is dropped
+	doc := NewDocument(rs, bs)
+	assert.Equal(t, []string{"// the board"}, doc.Comments())
+	assert.Equal(t, "// the board\n\ntodo\n", doc.Serialise(ser, ktask.NewRecord(ktask.Todo)))
+}
+
+func TestRepair(t *testing.T) {
+	stages := ktask.Stages{"todo", "done"}
+	for _, test := range []struct {
+		text   string
+		expect string
+	}{
+		{"  todo\n    2024-01-02 2024-01-03 buy milk\n", "todo\n    2024-01-02 2024-01-03 buy milk\n"},
+		{"todo\n\t2024-01-02 2024-01-03 buy milk\n\t  notes\n", "todo\n\t2024-01-02 2024-01-03 buy milk\n\t\tnotes\n"},
+		{"todo\n    2024-01-02 buy milk\r\n", "todo\n    2024-01-02 2024-01-02 buy milk\r\n"},
+		{"Todo\n    2024-01-02 2024-01-03 buy milk\n", "todo\n    2024-01-02 2024-01-03 buy milk\n"},
+		{"todo soon\n    2024-01-02 2024-01-03 buy milk\n", "// soon\ntodo\n    2024-01-02 2024-01-03 buy milk\n"},
+		{"todo\n    2024-01-02 2024-01-03 buy milk\ndone\n    2024-01-01 2024-01-01 x", "todo\n    2024-01-02 2024-01-03 buy milk\n\ndone\n    2024-01-01 2024-01-01 x"},
+		// mis-indented stages aren't mistaken for notes
+		{"todo\n    2024-01-02 2024-01-03 buy milk\n  done\n    2024-01-01 2024-01-01 x\n", "todo\n    2024-01-02 2024-01-03 buy milk\n\ndone\n    2024-01-01 2024-01-01 x\n"},
+		{"todo\n    2024-01-02 2024-01-03 buy milk\n    done\n", "todo\n    2024-01-02 2024-01-03 buy milk\n\ndone\n"},
+		{"todo\n    2024-01-02 2024-01-03 buy milk\n  two bottles\n", "todo\n    2024-01-02 2024-01-03 buy milk\n        two bottles\n"},
+	} {
+		repaired, repairs, errs := Repair(test.text, stages)
+		assert.Nil(t, errs, test.text)
+		assert.NotEmpty(t, repairs, test.text)
+		assert.Equal(t, test.expect, repaired, test.text)
+	}
+
+	// without declared stages, text followed by entries could be a stage
+	text := "todo\n    2024-01-02 2024-01-03 buy milk\n  doing\n    2024-01-01 2024-01-01 x\n"
+	repaired, repairs, errs := Repair(text, nil)
+	assert.Nil(t, errs)
+	assert.Equal(t, "todo\n    2024-01-02 2024-01-03 buy milk\n\ndoing\n    2024-01-01 2024-01-01 x\n", repaired)
+	assert.Len(t, repairs, 1)
+
+	// malformed dates can't be repaired safely
+	text = "todo\n    2024-1-02 2024-01-03 buy milk\n"
+	repaired, repairs, errs = Repair(text, stages)
+	assert.Equal(t, text, repaired)
+	assert.Empty(t, repairs)
+	require.Len(t, errs, 1)
+	assert.Equal(t, ErrorMalformedCreatedAt().toErrData(2, 4, 9), toErrData(errs[0]))
+}
+
+func TestParseLenient(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-03 buy milk
+    2024-1-02 2024-01-03 typo
+        notes of the typo
+    2024-01-04 2024-01-05 water plants
+
+Doing
+    2024-01-02 2024-01-03 undeclared
+
+done
+    2024-01-01 2024-01-01 celebrate
+`
+	rs, bs, errs := ParseLenient(text, ktask.Stages{"todo", "done"})
+	require.Len(t, rs, 2)
+	require.Len(t, errs, 2)
+	assert.Equal(t, ErrorMalformedCreatedAt().toErrData(3, 4, 9), toErrData(errs[0]))
+	assert.Equal(t, ErrorInvalidStage().toErrData(7, 0, 5), toErrData(errs[1]))
+	require.Len(t, rs[0].Entries(), 2)
+	require.Len(t, rs[1].Entries(), 1)
+	assert.Equal(t, []string{
+		ktask.QuarantineLine("    2024-1-02 2024-01-03 typo"),
+		ktask.QuarantineLine("        notes of the typo"),
+	}, rs[0].Entries()[1].Comments())
+
+	ser := NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	doc := NewDocument(rs, bs)
+	assert.Equal(t, text, doc.Serialise(ser, rs...))
+
+	// the quarantined lines stay with the entry they precede
+	moved := rs[0].RemoveEntry(1)
+	rs[1].AppendEntry(moved)
+	assert.Equal(t, `todo
+    2024-01-02 2024-01-03 buy milk
+
+Doing
+    2024-01-02 2024-01-03 undeclared
+
+done
+    2024-01-01 2024-01-01 celebrate
+    2024-1-02 2024-01-03 typo
+        notes of the typo
+    2024-01-04 2024-01-05 water plants
+`, doc.Serialise(ser, rs...))
+
+	_, _, errs = ParseLenient("Doing\n    2024-01-02 2024-01-03 undeclared\n", ktask.Stages{"todo"})
+	require.Len(t, errs, 1)
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	rs, _, errs := NewSerialParser().Parse(`todo
+    2024-01-02 2024-01-03 #grocery buy milk #due=2024-01-05
+        two bottles
+    2024-01-02 2024-02-01 fix a | b
+        *really* [soon] <b>
+
+done
+    2023-12-01 2024-01-01 learn C# with_underscores
+`)
+	require.Nil(t, errs)
+
+	md := NewMarkdownRenderer(false, ktask.Done)
+	assert.Equal(t, "## todo\n\n"+
+		"- [ ] buy milk `#grocery` `#due=2024-01-05` (created 2024-01-02, modified 2024-01-03)\n"+
+		"  - two bottles\n"+
+		"- [ ] fix a | b (created 2024-01-02, modified 2024-02-01)\n"+
+		"  - \\*really\\* \\[soon\\] &lt;b&gt;\n"+
+		"\n## done\n\n"+
+		"- [x] learn C\\# with\\_underscores (created 2023-12-01, modified 2024-01-01)\n", md.Render(rs...))
+
+	md = NewMarkdownRenderer(true, ktask.Done)
+	assert.Equal(t, "## todo\n\n"+
+		"| Title | Tags | Created | Modified | Notes |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| buy milk | `#grocery` `#due=2024-01-05` | 2024-01-02 | 2024-01-03 | two bottles |\n"+
+		"| fix a \\| b |  | 2024-01-02 | 2024-02-01 | \\*really\\* \\[soon\\] &lt;b&gt; |\n"+
+		"\n## done\n\n"+
+		"| Title | Tags | Created | Modified | Notes |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| learn C\\# with\\_underscores |  | 2023-12-01 | 2024-01-01 |  |\n", md.Render(rs...))
+
+	assert.Equal(t, "## todo\n\n_No entries._\n", md.Render(ktask.NewRecord(ktask.Todo)))
+}
+
+func TestRenderHTML(t *testing.T) {
+	rs, _, errs := NewSerialParser().Parse(`todo
+    2024-01-02 2024-01-03 #grocery <b>milk</b> #due=2024-01-05
+        two bottles
+
+done
+    2023-12-01 2024-01-01 #grocery=shop celebrate
+`)
+	require.Nil(t, errs)
+
+	out := NewHTMLRenderer("tasks").Render(rs...)
+	assert.Contains(t, out, `<div class="title">&lt;b&gt;milk&lt;/b&gt;</div>`)
+	assert.Contains(t, out, `<li>two bottles</li>`)
+	assert.Contains(t, out, `<span class="due">due 2024-01-05</span>`)
+	assert.NotContains(t, out, `src=`)
+	// tags with the same name share the colour
+	colour := string(tagColour("grocery"))
+	assert.Contains(t, out, `style="background: `+colour+`">#grocery</span>`)
+	assert.Contains(t, out, `style="background: `+colour+`">#grocery=shop</span>`)
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jotaen/klog/klog/parser/txt"
+)
+
+// maxRepairRounds limits how often the text is parsed again after repairing
+// it, repairing one error might reveal another one.
+const maxRepairRounds = 100
+
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([ \t]|$)`)
+
+// Repair applies safe repairs to the text for common errors: wrong
+// indentation (of stages, entries and notes), a missing modification date,
+// the wrong casing of a declared stage and text following the stage in the
+// headline. It returns the repaired text, a description of each repair and
+// the errors which couldn't be repaired.
+func Repair(text string, stages ktask.Stages) (string, []string, []txt.Error) {
+	var repairs []string
+	for round := 0; round < maxRepairRounds; round++ {
+		_, _, errs := NewSerialParserWithStages(stages).Parse(text)
+		if errs == nil {
+			return text, repairs, nil
+		}
+		lines := strings.SplitAfter(text, "\n")
+		// repair from the bottom up, so that inserted lines don't shift the
+		// lines of the other errors
+		slices.SortStableFunc(errs, func(a, b txt.Error) int { return b.LineNumber() - a.LineNumber() })
+		repaired := map[int]bool{}
+		for _, e := range errs {
+			i := e.LineNumber() - 1
+			if repaired[i] || i >= len(lines) {
+				continue
+			}
+			fixed, desc := repairLine(lines, i, e, stages)
+			if fixed == nil {
+				continue
+			}
+			lines = fixed
+			repaired[i] = true
+			repairs = append(repairs, fmt.Sprintf("%s: %s", desc, strings.TrimSpace(e.LineText())))
+		}
+		if fixed := strings.Join(lines, ""); fixed != text {
+			text = fixed
+			continue
+		}
+		return text, repairs, errs
+	}
+	_, _, errs := NewSerialParserWithStages(stages).Parse(text)
+	return text, repairs, errs
+}
+
+// splitEnding splits a line into its text and its line ending.
+func splitEnding(l string) (string, string) {
+	text := strings.TrimRight(l, "\r\n")
+	return text, l[len(text):]
+}
+
+// lineEnding returns the line ending to use for a line inserted before the
+// line i.
+func lineEnding(lines []string, i int) string {
+	for j := i; j >= 0; j-- {
+		if _, ending := splitEnding(lines[j]); ending != "" {
+			return ending
+		}
+	}
+	return "\n"
+}
+
+// indentation returns the indentation of the entries of the stage the line i
+// belongs to. It defaults to four spaces.
+func indentation(lines []string, i int) string {
+	valid := func(indentation string) bool {
+		return slices.Contains(txt.Indentations, indentation)
+	}
+	// the closest entry before the line, or otherwise after it
+	for j := i - 1; j >= 0 && strings.TrimSpace(lines[j]) != ""; j-- {
+		text, _ := splitEnding(lines[j])
+		trimmed := strings.TrimLeft(text, " \t")
+		if datePattern.MatchString(trimmed) && valid(text[:len(text)-len(trimmed)]) {
+			return text[:len(text)-len(trimmed)]
+		}
+	}
+	for j := i + 1; j < len(lines) && strings.TrimSpace(lines[j]) != ""; j++ {
+		text, _ := splitEnding(lines[j])
+		trimmed := strings.TrimLeft(text, " \t")
+		if datePattern.MatchString(trimmed) && valid(text[:len(text)-len(trimmed)]) {
+			return text[:len(text)-len(trimmed)]
+		}
+	}
+	return "    "
+}
+
+// isHeadline checks whether the line i starts a stage, i.e. it is the first
+// line after a blank line, apart from comments.
+func isHeadline(lines []string, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		text, _ := splitEnding(lines[j])
+		if strings.TrimSpace(text) == "" {
+			return true
+		}
+		if !isComment(txt.Line{Text: text}) {
+			return false
+		}
+	}
+	return true
+}
+
+// isStage checks whether the line i could be meant as the headline of a stage:
+// it names a declared stage or, if no stages were declared, entries follow it.
+func isStage(lines []string, i int, stages ktask.Stages) bool {
+	text, _ := splitEnding(lines[i])
+	trimmed := strings.TrimSpace(text)
+	if len(stages) > 0 {
+		return slices.ContainsFunc(stages, func(s ktask.Stage) bool { return strings.EqualFold(string(s), trimmed) })
+	}
+	if i+1 >= len(lines) {
+		return false
+	}
+	next, _ := splitEnding(lines[i+1])
+	return datePattern.MatchString(strings.TrimLeft(next, " \t"))
+}
+
+// repairLine repairs the line i for the error. It returns the repaired lines
+// and a description of the repair, or nil if the error can't be repaired
+// safely.
+func repairLine(lines []string, i int, e txt.Error, stages ktask.Stages) ([]string, string) {
+	text, ending := splitEnding(lines[i])
+	trimmed := strings.TrimLeft(text, " \t")
+	replace := func(desc string, ls ...string) ([]string, string) {
+		return slices.Concat(lines[:i], ls, lines[i+1:]), desc
+	}
+
+	switch e.Code() {
+	case ErrorIllegalIndentation().code:
+		switch {
+		case datePattern.MatchString(trimmed):
+			return replace("indented the entry", indentation(lines, i)+trimmed+ending)
+		case isHeadline(lines, i):
+			return replace("removed the indentation of the stage", trimmed+ending)
+		case trimmed == text:
+			return replace("separated the stage from the previous one", lineEnding(lines, i-1), lines[i])
+		case isStage(lines, i, stages):
+			return replace("separated the stage from the previous one", lineEnding(lines, i-1), trimmed+ending)
+		default:
+			return replace("indented the notes", strings.Repeat(indentation(lines, i), 2)+trimmed+ending)
+		}
+
+	case ErrorMalformedCreatedAt().code:
+		// Text without dates following an entry is most likely meant as
+		// notes, unless it could be a stage. Malformed dates are not repaired.
+		if strings.ContainsAny(trimmed[:1], "0123456789") || isHeadline(lines, i) {
+			return nil, ""
+		}
+		if isStage(lines, i, stages) {
+			return replace("separated the stage from the previous one", lineEnding(lines, i-1), trimmed+ending)
+		}
+		return replace("indented the notes", strings.Repeat(indentation(lines, i), 2)+trimmed+ending)
+
+	case ErrorMalformedModifiedAt().code:
+		// Only add the date if it is missing, not if it is malformed.
+		fields := strings.Fields(trimmed)
+		if len(fields) == 0 || !datePattern.MatchString(fields[0]) {
+			return nil, ""
+		}
+		if len(fields) > 1 && strings.ContainsAny(fields[1][:1], "0123456789") {
+			return nil, ""
+		}
+		indent := text[:len(text)-len(trimmed)]
+		rest := strings.TrimPrefix(trimmed, fields[0])
+		return replace("added the missing modification date", indent+fields[0]+" "+fields[0]+rest+ending)
+
+	case ErrorInvalidStage().code:
+		// Only the casing of declared stages is repaired.
+		var match ktask.Stage
+		for _, s := range stages {
+			prefix := trimmed[:min(len(s), len(trimmed))]
+			rest := trimmed[len(prefix):]
+			if len(s) > len(match) && strings.EqualFold(prefix, string(s)) && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+				match = s
+			}
+		}
+		if match == "" || text != trimmed {
+			return nil, ""
+		}
+		return replace(fmt.Sprintf("renamed the stage to %q", match), string(match)+text[len(match):]+ending)
+
+	case ErrorUnrecognisedTextInHeadline().code:
+		runes := []rune(text)
+		if e.Position() > len(runes) {
+			return nil, ""
+		}
+		stage := strings.TrimRight(string(runes[:e.Position()]), " \t")
+		comment := strings.TrimSpace(string(runes[e.Position():]))
+		return replace("moved the text after the stage into a comment", "// "+comment+lineEnding(lines, i), stage+ending)
+	}
+	return nil, ""
+}
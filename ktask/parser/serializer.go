@@ -11,6 +11,8 @@ type Serialiser interface {
 	Date(time.Time) string
 	Stage(ktask.Stage) string
 	Name(NameText) string
+	Notes(string) string
+	Comment(string) string
 }
 
 type Line struct {
@@ -48,20 +50,60 @@ func SerialiseRecords(s Serialiser, rs ...ktask.Record) Lines {
 
 func serialiseRecord(s Serialiser, r ktask.Record) []Line {
 	var lines []Line
+	for _, c := range r.Comments() {
+		lines = append(lines, Line{commentLine(s, "", c), r, -1})
+	}
+	if IsCommentRecord(r) {
+		return lines
+	}
 	headline := s.Stage(r.Stage())
 	lines = append(lines, Line{headline, r, -1})
 	for entryI, e := range r.Entries() {
-		cValue := s.Date(e.CreatedAt())
-		mValue := s.Date(e.ModifiedAt())
-		lines = append(lines, Line{canonicalIndentation + cValue + " " + mValue, r, entryI})
-		for i, l := range e.Name().Lines() {
-			summaryText := s.Name([]string{l})
-			if i == 0 && l != "" {
-				lines[len(lines)-1].Text += " " + summaryText
-			} else if i >= 1 {
-				lines = append(lines, Line{canonicalIndentation + canonicalIndentation + summaryText, r, entryI})
-			}
+		lines = append(lines, serialiseEntry(s, r, e, entryI, canonicalIndentation)...)
+	}
+	lines = append(lines, serialiseTrailingComments(s, r, canonicalIndentation)...)
+	return lines
+}
+
+func serialiseTrailingComments(s Serialiser, r ktask.Record, indentation string) []Line {
+	var lines []Line
+	for _, c := range r.TrailingComments() {
+		lines = append(lines, Line{commentLine(s, indentation, c), r, -1})
+	}
+	return lines
+}
+
+// commentLine serialises a comment using the given indentation. Quarantined
+// lines are written verbatim.
+func commentLine(s Serialiser, indentation string, c string) string {
+	if line, ok := ktask.QuarantinedLine(c); ok {
+		return line
+	}
+	return indentation + s.Comment(c)
+}
+
+// serialiseEntry serialises an entry using the given indentation.
+func serialiseEntry(s Serialiser, r ktask.Record, e ktask.Entry, entryI int, indentation string) []Line {
+	var lines []Line
+	for _, c := range e.Comments() {
+		lines = append(lines, Line{commentLine(s, indentation, c), r, entryI})
+	}
+	cValue := s.Date(e.CreatedAt())
+	mValue := s.Date(e.ModifiedAt())
+	lines = append(lines, Line{indentation + cValue + " " + mValue, r, entryI})
+	for i, l := range e.Name().Lines() {
+		if i == 0 && e.ID() != "" {
+			l = strings.TrimLeft(l+" "+ktask.NewTagOrPanic(ktask.IdTagName, e.ID()).ToString(), " ")
 		}
+		summaryText := s.Name([]string{l})
+		if i == 0 && l != "" {
+			lines[len(lines)-1].Text += " " + summaryText
+		} else if i >= 1 {
+			lines = append(lines, Line{indentation + indentation + summaryText, r, entryI})
+		}
+	}
+	for _, n := range e.Notes() {
+		lines = append(lines, Line{indentation + indentation + s.Notes(n), r, entryI})
 	}
 	return lines
 }
@@ -38,3 +38,11 @@ func (cs TextSerialiser) Name(s NameText) string {
 	})
 	return summaryStyler.Format(txt)
 }
+
+func (cs TextSerialiser) Notes(n string) string {
+	return cs.Styler.Props(tf.StyleProps{Color: tf.SUBDUED}).Format(n)
+}
+
+func (cs TextSerialiser) Comment(c string) string {
+	return cs.Styler.Props(tf.StyleProps{Color: tf.SUBDUED}).Format(c)
+}
@@ -0,0 +1,267 @@
+/*
+Package todotxt contains the logic of converting Record’s to the todo.txt
+format (see https://github.com/todotxt/todo.txt) and back.
+
+Every entry becomes one line. The entries of the done stage are completed
+(`x`) with the modification date as completion date, the other ones carry
+their stage as `stage:` key, so that it is kept when reading them back. The
+project of an entry becomes `+project`, other tags become `@context` or
+`key:value` if they have a value and the priority becomes `(A)`.
+*/
+package todotxt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"ktask/ktask"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// The keys with a special meaning, all other keys become tags.
+const (
+	StageKey    = "stage"
+	ModifiedKey = "modified"
+	PrioKey     = "pri"
+)
+
+var (
+	wordPattern     = regexp.MustCompile(`^[\p{L}\d_-]+$`)
+	keyValuePattern = regexp.MustCompile(`^(\p{L}[\p{L}\d_-]*):(.+)$`)
+	priorityPattern = regexp.MustCompile(`^\(([A-Z])\)$`)
+)
+
+// ToTodoTxt converts the entries of the records to todo.txt lines. Entries of
+// the done stage are completed. The lines of the name are joined with blanks,
+// the notes are omitted as todo.txt has no equivalent.
+func ToTodoTxt(rs []ktask.Record, done ktask.Stage) string {
+	var b strings.Builder
+	for _, r := range rs {
+		for _, e := range r.Entries() {
+			b.WriteString(toLine(r.Stage(), e, done) + "\n")
+		}
+	}
+	return b.String()
+}
+
+func toLine(stage ktask.Stage, e ktask.Entry, done ktask.Stage) string {
+	var parts []string
+	prio, hasPrio := e.Priority()
+	if stage == done {
+		parts = append(parts, "x", e.ModifiedAt().Format(dateFormat))
+	} else if hasPrio {
+		parts = append(parts, "("+prio.ToString()+")")
+	}
+	parts = append(parts, e.CreatedAt().Format(dateFormat))
+
+	project, hasProject := e.Name().Project()
+	text := ktask.HashTagPattern.ReplaceAllStringFunc(strings.Join(e.Name().Lines(), " "), func(m string) string {
+		t, err := ktask.NewTagFromString(m)
+		switch {
+		case err != nil:
+			return m
+		case t.Name() == ktask.PrioTagName:
+			return ""
+		case t.Value() != "":
+			return t.Name() + ":" + quote(t.Value())
+		case hasProject && t == project:
+			return "+" + t.Name()
+		default:
+			return "@" + t.Name()
+		}
+	})
+	parts = append(parts, strings.Fields(text)...)
+
+	if e.ID() != "" {
+		parts = append(parts, ktask.IdTagName+":"+quote(e.ID()))
+	}
+	if stage != done {
+		parts = append(parts, StageKey+":"+quote(string(stage)))
+		if !e.ModifiedAt().Equal(e.CreatedAt()) {
+			parts = append(parts, ModifiedKey+":"+e.ModifiedAt().Format(dateFormat))
+		}
+	} else if hasPrio {
+		parts = append(parts, PrioKey+":"+prio.ToString())
+	}
+	return strings.Join(parts, " ")
+}
+
+// quote encloses values which contain blanks (or other characters a key
+// can't contain) in quotes, like ktask does for the values of tags.
+func quote(v string) string {
+	if wordPattern.MatchString(v) {
+		return v
+	}
+	if strings.Contains(v, `"`) {
+		return "'" + v + "'"
+	}
+	return `"` + v + `"`
+}
+
+// FromTodoTxt reads records from todo.txt lines. Completed lines go to the
+// done stage, unless they have a `stage:` key, incomplete ones without such a
+// key to the todo stage. Lines without creation date are created today, or
+// when they were completed/modified if that was before. The entries are
+// grouped by stage in the order of their first occurrence.
+func FromTodoTxt(data []byte, todo ktask.Stage, done ktask.Stage, today time.Time) ([]ktask.Record, error) {
+	var rs []ktask.Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for nr := 1; scanner.Scan(); nr++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" {
+			continue
+		}
+		stage, e, err := fromLine(line, todo, done, today)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", nr, err)
+		}
+		ri := slices.IndexFunc(rs, func(r ktask.Record) bool { return r.Stage() == stage })
+		if ri < 0 {
+			rs = append(rs, ktask.NewRecord(stage))
+			ri = len(rs) - 1
+		}
+		// the position of the entry is only known once its stage is
+		entry := ktask.NewEntry(e.Name(), e.CreatedAt(), e.ModifiedAt(), len(rs[ri].Entries()))
+		entry.SetID(e.ID())
+		rs[ri].AppendEntry(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func fromLine(line string, todo ktask.Stage, done ktask.Stage, today time.Time) (ktask.Stage, ktask.Entry, error) {
+	words := splitWords(line)
+	date := func() (time.Time, bool) {
+		if len(words) == 0 {
+			return time.Time{}, false
+		}
+		d, err := time.Parse(dateFormat, words[0])
+		if err != nil {
+			return time.Time{}, false
+		}
+		words = words[1:]
+		return d, true
+	}
+
+	stage := todo
+	var prio string
+	var createdAt, modifiedAt time.Time
+	var hasCreatedAt bool
+	if len(words) > 0 && words[0] == "x" {
+		words = words[1:]
+		stage = done
+		if completedAt, ok := date(); ok {
+			modifiedAt = completedAt
+			createdAt, hasCreatedAt = date()
+		}
+	} else {
+		if len(words) > 0 && priorityPattern.MatchString(words[0]) {
+			prio = priorityPattern.FindStringSubmatch(words[0])[1]
+			words = words[1:]
+		}
+		createdAt, hasCreatedAt = date()
+	}
+	if !hasCreatedAt {
+		createdAt = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+		// an entry can't be created after it was completed
+		if !modifiedAt.IsZero() && modifiedAt.Before(createdAt) {
+			createdAt = modifiedAt
+		}
+	}
+	if modifiedAt.IsZero() {
+		modifiedAt = createdAt
+	}
+
+	var text []string
+	var id string
+	for _, w := range words {
+		if (strings.HasPrefix(w, "+") || strings.HasPrefix(w, "@")) && wordPattern.MatchString(w[1:]) {
+			text = append(text, "#"+w[1:])
+			continue
+		}
+		m := keyValuePattern.FindStringSubmatch(w)
+		if m == nil || strings.HasPrefix(m[2], "/") || (strings.Contains(m[2], ":") && unquote(m[2]) == m[2]) {
+			text = append(text, w)
+			continue
+		}
+		key, value := strings.ToLower(m[1]), unquote(m[2])
+		switch key {
+		case StageKey:
+			stage = ktask.Stage(value)
+			if err := stage.Valid(); err != nil {
+				return "", ktask.Entry{}, fmt.Errorf("invalid stage %q", value)
+			}
+		case ModifiedKey:
+			d, err := time.Parse(dateFormat, value)
+			if err != nil {
+				return "", ktask.Entry{}, fmt.Errorf("invalid modified %q, expected YYYY-MM-DD", value)
+			}
+			modifiedAt = d
+		case PrioKey:
+			prio = value
+		case ktask.IdTagName:
+			if t, err := ktask.NewTagFromString(ktask.IdTagName + "=" + value); err != nil || t.Value() != value {
+				return "", ktask.Entry{}, fmt.Errorf("invalid id %q", value)
+			}
+			id = value
+		default:
+			t, err := ktask.NewTag(key, value)
+			if err != nil {
+				return "", ktask.Entry{}, fmt.Errorf("invalid value %q of %q", value, key)
+			}
+			text = append(text, t.ToString())
+		}
+	}
+	if len(text) == 0 {
+		return "", ktask.Entry{}, errors.New("the title is missing")
+	}
+	if prio != "" {
+		p, err := ktask.NewPriority(prio)
+		if err != nil {
+			return "", ktask.Entry{}, fmt.Errorf("invalid priority %q, expected a letter from A to Z", prio)
+		}
+		text = append(text, ktask.NewTagOrPanic(ktask.PrioTagName, p.ToString()).ToString())
+	}
+
+	e := ktask.NewEntry(ktask.Name{strings.Join(text, " ")}, createdAt, modifiedAt, 0)
+	e.SetID(id)
+	return stage, e, nil
+}
+
+// splitWords splits the line at blanks, except for blanks within the quoted
+// value of a key, e.g. `stage:"in progress"`.
+func splitWords(line string) []string {
+	var words []string
+	fields := strings.Fields(line)
+	for i := 0; i < len(fields); i++ {
+		w := fields[i]
+		if m := keyValuePattern.FindStringSubmatch(w); m != nil && strings.ContainsAny(m[2][:1], `"'`) {
+			q := m[2][:1]
+			for j := i; j < len(fields); j++ {
+				joined := strings.Join(fields[i:j+1], " ")
+				if v := joined[len(m[1])+1:]; len(v) > 1 && strings.HasSuffix(v, q) {
+					w, i = joined, j
+					break
+				}
+			}
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+// unquote removes the quotes around a value.
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
@@ -0,0 +1,89 @@
+package todotxt
+
+import (
+	"ktask/ktask"
+	"ktask/ktask/parser"
+	"testing"
+	"time"
+
+	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	text := `todo
+    2024-01-02 2024-01-02 #grocery buy milk #store #due=2024-01-05 #id=3fa2c1
+    2024-01-02 2024-01-04 water plants #where="living room" #prio=A
+
+in progress
+    2024-01-03 2024-01-06 #work=social send newsletter
+
+done
+    2023-12-01 2024-01-01 celebrate #friends #prio=B
+`
+	rs, _, errs := parser.NewSerialParser().Parse(text)
+	require.Nil(t, errs)
+
+	out := ToTodoTxt(rs, ktask.Done)
+	assert.Equal(t, `2024-01-02 +grocery buy milk @store due:2024-01-05 id:3fa2c1 stage:todo
+(A) 2024-01-02 water plants where:"living room" stage:todo modified:2024-01-04
+2024-01-03 work:social send newsletter stage:"in progress" modified:2024-01-06
+x 2024-01-01 2023-12-01 celebrate +friends pri:B
+`, out)
+
+	imported, err := FromTodoTxt([]byte(out), ktask.Todo, ktask.Done, time.Now())
+	require.Nil(t, err)
+	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	assert.Equal(t, text, parser.SerialiseRecords(ser, imported...).ToString())
+}
+
+func TestTodoTxtImport(t *testing.T) {
+	today := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rs, err := FromTodoTxt([]byte(`(B) call mom @phone +Family
+x 2024-02-01 2024-01-20 file taxes
+x done without dates
+x 2024-02-05 done without creation date
+2024-02-10 read https://example.com at 10:30 rec:+1w
+`), ktask.Todo, ktask.Done, today)
+	require.Nil(t, err)
+	ser := parser.NewSerialiser(tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR), false)
+	assert.Equal(t, `todo
+    2024-03-01 2024-03-01 call mom #phone #Family #prio=B
+    2024-02-10 2024-02-10 read https://example.com at 10:30 #rec="+1w"
+
+done
+    2024-01-20 2024-02-01 file taxes
+    2024-03-01 2024-03-01 done without dates
+    2024-02-05 2024-02-05 done without creation date
+`, parser.SerialiseRecords(ser, rs...).ToString())
+
+	for _, invalid := range []string{
+		"2024-01-02 buy milk modified:yesterday\n",
+		"2024-01-02 buy milk pri:1\n",
+		"2024-01-02 +grocery stage:\" todo\"\n",
+		"(A) 2024-01-02\n",
+	} {
+		_, err := FromTodoTxt([]byte(invalid), ktask.Todo, ktask.Done, today)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestTodoTxtDeclaredStages(t *testing.T) {
+	rs, _, errs := parser.NewSerialParser().Parse("backlog\n    2024-01-02 2024-01-02 buy milk\n\nfinished\n    2024-01-01 2024-01-03 celebrate\n")
+	require.Nil(t, errs)
+	stages, err := ktask.NewStages("backlog", "finished")
+	require.Nil(t, err)
+
+	out := ToTodoTxt(rs, stages.Done())
+	assert.Equal(t, `2024-01-02 buy milk stage:backlog
+x 2024-01-03 2024-01-01 celebrate
+`, out)
+
+	imported, err := FromTodoTxt([]byte(out+"read the news\n"), stages.Todo(), stages.Done(), time.Now())
+	require.Nil(t, err)
+	require.Len(t, imported, 2)
+	assert.Equal(t, ktask.Stage("backlog"), imported[0].Stage())
+	assert.Len(t, imported[0].Entries(), 2)
+	assert.Equal(t, ktask.Stage("finished"), imported[1].Stage())
+}
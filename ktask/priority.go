@@ -0,0 +1,42 @@
+package ktask
+
+import (
+	"errors"
+	"strings"
+)
+
+// PrioTagName is the name of the tag which holds the priority of an entry,
+// e.g. `#prio=A`.
+const PrioTagName = "prio"
+
+// Priority is the priority of an entry, ranging from A (highest) to Z
+// (lowest).
+type Priority rune
+
+// NewPriority parses a priority, lowercase letters are accepted as well.
+func NewPriority(s string) (Priority, error) {
+	s = strings.ToUpper(s)
+	if len(s) != 1 || s[0] < 'A' || s[0] > 'Z' {
+		return 0, errors.New("INVALID_PRIORITY")
+	}
+	return Priority(s[0]), nil
+}
+
+func (p Priority) ToString() string {
+	return string(p)
+}
+
+// Priority returns the priority of the entry and whether it has one.
+func (e Entry) Priority() (Priority, bool) {
+	for _, t := range e.name.Tags().All() {
+		if t.Name() != PrioTagName {
+			continue
+		}
+		p, err := NewPriority(t.Value())
+		if err != nil {
+			continue
+		}
+		return p, true
+	}
+	return 0, false
+}
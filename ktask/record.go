@@ -2,6 +2,8 @@ package ktask
 
 import (
 	"errors"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -13,7 +15,18 @@ type Record interface {
 	SetEntries([]Entry)
 	// AddEntry adds an entry to the record
 	AddEntry(n Name, c, m time.Time, i int)
-	addEntry(e Entry)
+	// AppendEntry appends an existing entry to the record
+	AppendEntry(e Entry)
+	// RemoveEntry removes the entry at the given position and returns it.
+	RemoveEntry(i int) Entry
+	// Comments returns the comment lines preceding the headline.
+	Comments() []string
+	SetComments([]string)
+	// TrailingComments returns the comment lines following the last entry.
+	TrailingComments() []string
+	SetTrailingComments([]string)
+	// SplitOnFunc splits the entries of the record, the comments are kept
+	// in the first one.
 	SplitOnFunc(pred func(e *Entry) bool) (Record, Record)
 	Merge(rs ...Record) error
 }
@@ -25,8 +38,10 @@ func NewRecord(stage Stage) Record {
 }
 
 type record struct {
-	stage   Stage
-	entries []Entry
+	stage    Stage
+	entries  []Entry
+	comments []string
+	trailing []string
 }
 
 func (r *record) Stage() Stage {
@@ -45,19 +60,43 @@ func (r *record) AddEntry(n Name, c, m time.Time, i int) {
 	r.entries = append(r.entries, NewEntry(n, c, m, i))
 }
 
-func (r *record) addEntry(e Entry) {
+func (r *record) RemoveEntry(i int) Entry {
+	e := r.entries[i]
+	r.entries = slices.Delete(r.entries, i, i+1)
+	return e
+}
+
+func (r *record) AppendEntry(e Entry) {
 	r.entries = append(r.entries, e)
 }
 
+func (r *record) Comments() []string {
+	return r.comments
+}
+
+func (r *record) SetComments(cs []string) {
+	r.comments = cs
+}
+
+func (r *record) TrailingComments() []string {
+	return r.trailing
+}
+
+func (r *record) SetTrailingComments(cs []string) {
+	r.trailing = cs
+}
+
 func (r *record) SplitOnFunc(pred func(e *Entry) bool) (Record, Record) {
 	r1 := NewRecord(r.stage)
 	r2 := NewRecord(r.stage)
+	r1.SetComments(r.comments)
+	r1.SetTrailingComments(r.trailing)
 
 	for _, i := range r.entries {
 		if pred(&i) {
-			r1.addEntry(i)
+			r1.AppendEntry(i)
 		} else {
-			r2.addEntry(i)
+			r2.AppendEntry(i)
 		}
 	}
 
@@ -70,6 +109,23 @@ func (r *record) Merge(rs ...Record) error {
 			return errors.New("mismatching record stages ocurred during merge")
 		}
 		r.entries = append(r.entries, ro.Entries()...)
+		r.comments = append(r.comments, ro.Comments()...)
+		r.trailing = append(r.trailing, ro.TrailingComments()...)
 	}
 	return nil
 }
+
+// quarantineMark starts comments holding a line which couldn't be parsed.
+const quarantineMark = "\x00"
+
+// QuarantineLine turns a line which couldn't be parsed into a comment, so
+// that it is kept along with the entries and written back verbatim.
+func QuarantineLine(line string) string {
+	return quarantineMark + line
+}
+
+// QuarantinedLine returns the original line, if the comment holds a line
+// which couldn't be parsed.
+func QuarantinedLine(comment string) (string, bool) {
+	return strings.CutPrefix(comment, quarantineMark)
+}
@@ -0,0 +1,59 @@
+package ktask
+
+import (
+	"cmp"
+	"time"
+)
+
+// ByCreated compares entries by their creation date.
+func ByCreated(a, b Entry) int {
+	return a.CreatedAt().Compare(b.CreatedAt())
+}
+
+// ByModified compares entries by their modification date.
+func ByModified(a, b Entry) int {
+	return a.ModifiedAt().Compare(b.ModifiedAt())
+}
+
+// ByDue compares entries by their due date. Entries without a due date are
+// sorted last.
+func ByDue(a, b Entry) int {
+	ad, aok := a.Due()
+	bd, bok := b.Due()
+	return compareOptional(ad, aok, bd, bok, time.Time.Compare)
+}
+
+// ByPriority compares entries by their priority, the highest priority first.
+// Entries without a priority are sorted last.
+func ByPriority(a, b Entry) int {
+	ap, aok := a.Priority()
+	bp, bok := b.Priority()
+	return compareOptional(ap, aok, bp, bok, cmp.Compare[Priority])
+}
+
+// Chain combines comparisons, each one only decides if the previous ones
+// considered the entries equal.
+func Chain(cmps ...func(a, b Entry) int) func(a, b Entry) int {
+	return func(a, b Entry) int {
+		for _, cmp := range cmps {
+			if c := cmp(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
+
+// compareOptional compares two optional values, missing values are
+// considered greater than present ones.
+func compareOptional[T any](a T, aok bool, b T, bok bool, cmp func(T, T) int) int {
+	switch {
+	case aok && bok:
+		return cmp(a, b)
+	case aok:
+		return -1
+	case bok:
+		return 1
+	}
+	return 0
+}
@@ -1,6 +1,9 @@
 package ktask
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 type Stage string
 
@@ -10,9 +13,103 @@ const (
 	Done       Stage = "done"
 )
 
+// Valid checks whether the stage is well-formed. Stages can be named freely,
+// they only must not be empty, contain line breaks, start/end with whitespace
+// or start like a comment (`//` or `;`).
 func (s *Stage) Valid() error {
-	if *s != Todo && *s != InProgress && *s != Done {
+	if *s == "" || strings.TrimSpace(string(*s)) != string(*s) || strings.ContainsAny(string(*s), "\r\n") ||
+		strings.HasPrefix(string(*s), "//") || strings.HasPrefix(string(*s), ";") {
 		return errors.New("Invalid stage provided")
 	}
 	return nil
 }
+
+// Stages is the list of declared stages of a board, in the order in which
+// they should be shown. An empty list means that no stages were declared and
+// every well-formed stage is accepted.
+type Stages []Stage
+
+// NewStages creates a list of declared stages from their names.
+func NewStages(names ...string) (Stages, error) {
+	var ss Stages
+	for _, n := range names {
+		s := Stage(strings.TrimSpace(n))
+		if err := s.Valid(); err != nil {
+			return nil, err
+		}
+		if ss.Contains(s) {
+			return nil, errors.New("Stage declared multiple times: " + string(s))
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+// Contains checks whether the stage is part of the declared stages.
+func (ss Stages) Contains(s Stage) bool {
+	return ss.Index(s) >= 0
+}
+
+// Index returns the position of the stage in the declared stages, or -1 if
+// the stage was not declared.
+func (ss Stages) Index(s Stage) int {
+	for i, o := range ss {
+		if o == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Todo returns the stage new entries go to by default, which is the first
+// declared stage or todo if no stages were declared.
+func (ss Stages) Todo() Stage {
+	if len(ss) == 0 {
+		return Todo
+	}
+	return ss[0]
+}
+
+// Done returns the stage of completed entries, which is the last declared
+// stage or done if no stages were declared.
+func (ss Stages) Done() Stage {
+	if len(ss) == 0 {
+		return Done
+	}
+	return ss[len(ss)-1]
+}
+
+// Valid checks whether the stage is well-formed and declared. If no stages
+// were declared, every well-formed stage is valid.
+func (ss Stages) Valid(s Stage) error {
+	if err := s.Valid(); err != nil {
+		return err
+	}
+	if len(ss) > 0 && !ss.Contains(s) {
+		return errors.New("Stage not declared: " + string(s))
+	}
+	return nil
+}
+
+// Arrange brings the records in the declared order. Records of the same stage
+// are merged into one and declared stages without a record get an empty one.
+// Records whose stage was not declared are kept at the end in their original
+// order. If no stages were declared, the records are returned as they are.
+func (ss Stages) Arrange(rs []Record) []Record {
+	if len(ss) == 0 {
+		return rs
+	}
+	arranged := make([]Record, len(ss))
+	for i, s := range ss {
+		arranged[i] = NewRecord(s)
+	}
+	for _, r := range rs {
+		i := ss.Index(r.Stage())
+		if i < 0 {
+			arranged = append(arranged, r)
+			continue
+		}
+		arranged[i].Merge(r)
+	}
+	return arranged
+}
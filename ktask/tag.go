@@ -18,6 +18,10 @@ type Tag struct {
 }
 
 func NewTagFromString(tag string) (Tag, error) {
+	if strings.ContainsAny(tag, "\r\n") {
+		// Quoted values may contain anything but line breaks.
+		return Tag{}, errors.New("INVALID_TAG")
+	}
 	if !strings.HasPrefix(tag, "#") {
 		tag = "#" + tag
 	}
@@ -44,6 +48,17 @@ func NewTagFromString(tag string) (Tag, error) {
 	return NewTagOrPanic(name, value), nil
 }
 
+// NewTag constructs a new tag from its name and value. As opposed to
+// NewTagOrPanic, it fails if the tag can't be written to a file, e.g. because
+// the value contains a line break.
+func NewTag(name string, value string) (Tag, error) {
+	if !unquotedValuePattern.MatchString(name) || strings.ContainsAny(value, "\r\n") ||
+		(strings.Contains(value, "\"") && strings.Contains(value, "'")) {
+		return Tag{}, errors.New("INVALID_TAG")
+	}
+	return NewTagOrPanic(name, value), nil
+}
+
 // NewTagOrPanic constructs a new tag but will panic if the
 // parameters don’t yield a valid tag.
 func NewTagOrPanic(name string, value string) Tag {
@@ -116,6 +131,12 @@ func (ts *TagSet) ForLookup() map[Tag]bool {
 	return ts.lookup
 }
 
+// All returns the tags in their original order and without deduplication or
+// normalisation.
+func (ts *TagSet) All() []Tag {
+	return ts.original
+}
+
 // ToStrings returns the tags as string, in their original order
 // and without deduplication or normalisation.
 func (ts *TagSet) ToStrings() []string {
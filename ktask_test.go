@@ -0,0 +1,177 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ktask/ktask"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tempFile writes the content to a new file in a temporary directory.
+func tempFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "tasks.ktask")
+	require.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	require.Nil(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+func TestWriteDataRefusesUnreadableResult(t *testing.T) {
+	content := "todo\n    2024-01-02 2024-01-02 buy milk\n"
+	path := tempFile(t, content)
+	data, file, errK := readData(path, nil, false)
+	require.Nil(t, errK)
+	defer releaseLock(path)
+
+	// a name which bypassed the validation, e.g. by a faulty importer
+	data[0].Entries()[0].SetName(ktask.Name{"x\n\ndone"})
+	_, _, err := writeData(file, data, 0)
+	assert.ErrorContains(t, err, "can't be read back")
+
+	written, _ := os.ReadFile(path)
+	assert.Equal(t, content, string(written))
+}
+
+func TestWriteDataKeepsCommentsOfFileWithoutStages(t *testing.T) {
+	path := tempFile(t, "// my board\n")
+	data, file, errK := readData(path, nil, false)
+	require.Nil(t, errK)
+	defer releaseLock(path)
+	assert.Empty(t, data)
+
+	data = append(data, ktask.NewRecord(ktask.Todo))
+	_, _, err := writeData(file, data, 0)
+	require.Nil(t, err)
+
+	written, _ := os.ReadFile(path)
+	assert.Equal(t, "// my board\n\ntodo\n", string(written))
+}
+
+func TestLenientFileKeepsBrokenLinesAndRefreshesProblems(t *testing.T) {
+	content := "todo\n    2024-01-02 2024-01-03 buy milk\n    2024-1-02 2024-01-03 typo\n\ndone\n"
+	path := tempFile(t, content)
+	data, file, errK := readData(path, ktask.Stages{"todo", "done"}, true)
+	require.Nil(t, errK)
+	defer releaseLock(path)
+	require.Len(t, file.problems, 1)
+	assert.Equal(t, 3, file.problems[0].LineNumber())
+
+	// the broken line moves along with the entries around it
+	data[0].Entries()[0].SetName(ktask.Name{"buy oat milk"})
+	day := time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)
+	data[0].SetEntries(append([]ktask.Entry{ktask.NewEntry(ktask.Name{"water plants"}, day, day, 0)}, data[0].Entries()...))
+	_, _, err := writeData(file, data, 0)
+	require.Nil(t, err)
+	written, _ := os.ReadFile(path)
+	assert.Equal(t, "todo\n    2024-01-04 2024-01-04 water plants\n    2024-01-02 2024-01-03 buy oat milk\n    2024-1-02 2024-01-03 typo\n\ndone\n", string(written))
+	require.Len(t, file.problems, 1)
+	assert.Equal(t, 4, file.problems[0].LineNumber())
+
+	// the problems are gone once someone fixed the line
+	require.Nil(t, os.WriteFile(path, []byte(strings.Replace(string(written), "2024-1-02", "2024-01-02", 1)), 0o644))
+	merged, _, err := reloadData(file, data)
+	require.Nil(t, err)
+	require.Len(t, merged[0].Entries(), 3)
+	assert.Empty(t, file.problems)
+}
+
+func TestReloadDataMergesModifications(t *testing.T) {
+	path := tempFile(t, "todo\n    2024-01-02 2024-01-02 buy milk #id=aaaaaa\n\ndone\n")
+	data, file, errK := readData(path, nil, false)
+	require.Nil(t, errK)
+	defer releaseLock(path)
+
+	merged, _, err := reloadData(file, data)
+	require.Nil(t, err)
+	assert.Nil(t, merged)
+
+	// moved on the board, renamed in the file
+	data[1].AppendEntry(data[0].RemoveEntry(0))
+	require.Nil(t, os.WriteFile(path, []byte("todo\n    2024-01-02 2024-01-03 buy oat milk #id=aaaaaa\n\ndone\n"), 0o644))
+	merged, conflicts, err := reloadData(file, data)
+	require.Nil(t, err)
+	assert.Empty(t, conflicts)
+	require.Len(t, merged, 2)
+	assert.Empty(t, merged[0].Entries())
+	require.Len(t, merged[1].Entries(), 1)
+	assert.Equal(t, ktask.Name{"buy oat milk"}, merged[1].Entries()[0].Name())
+
+	// the file isn't written, but the next write is based on the reloaded file
+	_, _, err = writeData(file, merged, 0)
+	require.Nil(t, err)
+	written, _ := os.ReadFile(path)
+	assert.Equal(t, "todo\n\ndone\n    2024-01-02 2024-01-03 buy oat milk #id=aaaaaa\n", string(written))
+}
+
+func TestStagesDeclaredInFile(t *testing.T) {
+	path := tempFile(t, "// stages: todo, review, done\n\ntodo\n    2024-01-02 2024-01-03 buy milk\n\ndone\n")
+	data, stages, errK := loadData(path, nil)
+	require.Nil(t, errK)
+	assert.Equal(t, ktask.Stages{"todo", "review", "done"}, stages)
+	require.Len(t, data, 3)
+	assert.Equal(t, ktask.Stage("review"), data[1].Stage())
+
+	// the stages given on the command line override the declaration
+	_, _, errK = loadData(path, ktask.Stages{"todo", "doing"})
+	assert.NotNil(t, errK)
+
+	// undeclared stages are rejected when modifying the file
+	err := moveEntry(&argMove{File: path, Task: "todo:1", Stage: "doing"}, config{})
+	assert.Error(t, err)
+	captureStdout(t, func() {
+		require.Nil(t, moveEntry(&argMove{File: path, Task: "todo:1", Stage: "review"}, config{}))
+	})
+	content, _ := os.ReadFile(path)
+	assert.True(t, strings.HasPrefix(string(content), "// stages: todo, review, done\n\ntodo\n\nreview\n    2024-01-02 "), string(content))
+}
+
+func TestStagesDeclaredInFileStayAtTheTop(t *testing.T) {
+	for _, content := range []string{
+		// the first declared stage is missing
+		"// stages: todo, review, done\n\ndone\n    2024-01-02 2024-01-03 buy milk\n",
+		// the stages are out of order
+		"// stages: todo, review, done\ndone\n    2024-01-02 2024-01-03 buy milk\n\ntodo\n    2024-01-02 2024-01-03 call mom\n",
+	} {
+		path := tempFile(t, content)
+		require.Nil(t, formatData(&argFmt{File: path, Write: true}, config{}))
+		formatted, _ := os.ReadFile(path)
+		assert.True(t, strings.HasPrefix(string(formatted), strings.SplitAfter(content, "\n")[0]), string(formatted))
+		assert.NotContains(t, string(formatted), "\nreview\n")
+
+		captureStdout(t, func() {
+			require.Nil(t, addEntry(&argAdd{File: path, Stage: "done", Title: []string{"water plants"}}, config{}))
+		})
+		written, _ := os.ReadFile(path)
+		assert.True(t, strings.HasPrefix(string(written), strings.SplitAfter(content, "\n")[0]), string(written))
+		// no empty stages are written
+		assert.NotContains(t, string(written), "\nreview\n")
+		assert.False(t, strings.HasSuffix(string(written), "\n\n"), string(written))
+		assert.Equal(t, strings.Contains(content, "todo\n"), strings.Contains(string(written), "\ntodo\n"), string(written))
+
+		// the declaration is still in effect
+		err := addEntry(&argAdd{File: path, Stage: "blocked", Title: []string{"file taxes"}}, config{})
+		assert.Error(t, err)
+	}
+}
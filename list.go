@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"ktask/ktask/parser"
+	"ktask/ktask/parser/json"
+	"slices"
+	"time"
+
+	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
+)
+
+const dateFormat = "2006-01-02"
+
+// date is a command line argument in the form of YYYY-MM-DD.
+type date struct {
+	time.Time
+}
+
+func (d *date) UnmarshalText(b []byte) error {
+	t, err := time.Parse(dateFormat, string(b))
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", string(b))
+	}
+	d.Time = t
+	return nil
+}
+
+// inRange checks whether t lies within [after, before] on a per day basis.
+// Unset bounds are ignored.
+func inRange(t time.Time, after date, before date) bool {
+	day := t.Format(dateFormat)
+	if !after.IsZero() && day < after.Format(dateFormat) {
+		return false
+	}
+	if !before.IsZero() && day > before.Format(dateFormat) {
+		return false
+	}
+	return true
+}
+
+type argList struct {
+	File           string   `arg:"positional" help:"specify the file that should be read from"`
+	Tags           []string `arg:"--tags,-t,separate" help:"if set, only entries with this/these tags will be shown, may be specified multiple times"`
+	NoTags         []string `arg:"--no-tags,-T,separate" help:"if set, entries with this/these tags will NOT be shown, may be specified multiple times"`
+	Stage          []string `arg:"--stage,-s,separate" help:"if set, only this/these stages will be shown, may be specified multiple times"`
+	CreatedAfter   date     `arg:"--created-after" help:"only show entries created at or after this date (YYYY-MM-DD)"`
+	CreatedBefore  date     `arg:"--created-before" help:"only show entries created at or before this date (YYYY-MM-DD)"`
+	ModifiedAfter  date     `arg:"--modified-after" help:"only show entries modified at or after this date (YYYY-MM-DD)"`
+	ModifiedBefore date     `arg:"--modified-before" help:"only show entries modified at or before this date (YYYY-MM-DD)"`
+	DueAfter       date     `arg:"--due-after" help:"only show entries due at or after this date (YYYY-MM-DD)"`
+	DueBefore      date     `arg:"--due-before" help:"only show entries due at or before this date (YYYY-MM-DD)"`
+	Prio           string   `arg:"--prio" help:"only show entries with this priority or a higher one (A-Z)"`
+	Sort           string   `arg:"--sort" help:"sort the entries within each stage, one of created, modified, due or prio"`
+	Format         string   `arg:"--format" default:"plain" help:"output format, one of plain, colour or json"`
+	Pretty         bool     `arg:"--pretty" help:"pretty-print the json output"`
+}
+
+// filter returns a predicate which accepts the entries matching all filters
+// except for the stage.
+func (args *argList) filter() func(e *ktask.Entry) bool {
+	tags := tagFilter(args.Tags, args.NoTags)
+	return func(e *ktask.Entry) bool {
+		if args.Prio != "" {
+			min, _ := ktask.NewPriority(args.Prio)
+			p, ok := e.Priority()
+			if !ok || p > min {
+				return false
+			}
+		}
+		if !args.DueAfter.IsZero() || !args.DueBefore.IsZero() {
+			due, ok := e.Due()
+			if !ok || !inRange(due, args.DueAfter, args.DueBefore) {
+				return false
+			}
+		}
+		return tags(e) &&
+			inRange(e.CreatedAt(), args.CreatedAfter, args.CreatedBefore) &&
+			inRange(e.ModifiedAt(), args.ModifiedAfter, args.ModifiedBefore)
+	}
+}
+
+// order returns the comparison function to sort the entries with, or nil if
+// the entries should not be sorted.
+func (args *argList) order() (func(a, b ktask.Entry) int, error) {
+	switch args.Sort {
+	case "":
+		return nil, nil
+	case "created":
+		return ktask.ByCreated, nil
+	case "modified":
+		return ktask.ByModified, nil
+	case "due":
+		return ktask.ByDue, nil
+	case "prio":
+		return ktask.ByPriority, nil
+	}
+	return nil, usageError("Sort by created, modified, due or prio.", "unknown sort order %q", args.Sort)
+}
+
+// listData prints the (filtered) entries of the file grouped by their stage.
+func listData(args *argList, cfg config) error {
+	var styler tf.Styler
+	switch args.Format {
+	case "plain", "json":
+		styler = tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR)
+	case "colour", "color":
+		styler = tf.NewStyler(tf.COLOUR_THEME_DARK)
+	default:
+		return usageError("Use plain, colour or json.", "unknown output format %q", args.Format)
+	}
+
+	order, err := args.order()
+	if err != nil {
+		return err
+	}
+	if _, err := ktask.NewPriority(args.Prio); args.Prio != "" && err != nil {
+		return usageError("The priority is a letter from A (highest) to Z (lowest).", "invalid priority %q", args.Prio)
+	}
+
+	path := filePath(args.File)
+	data, _, errK := loadData(path, cfg.stages)
+	if pErrs, ok := errK.(ktask.ParserErrors); ok && args.Format == "json" {
+		// the errors are reported as json, only the exit code tells about them
+		fmt.Println(json.ToJson(nil, pErrs.All(), args.Pretty))
+		return ktask.NewErrorWithCode(errK.Code(), "The file contains errors", "Location: "+path, nil)
+	}
+	if errK != nil {
+		return errK
+	}
+
+	var shown []ktask.Record
+	for _, r := range data {
+		if len(args.Stage) > 0 && !slices.Contains(args.Stage, string(r.Stage())) {
+			continue
+		}
+		r, _ = r.SplitOnFunc(args.filter())
+		if order != nil {
+			slices.SortStableFunc(r.Entries(), order)
+		}
+		shown = append(shown, r)
+	}
+
+	if args.Format == "json" {
+		fmt.Println(json.ToJson(shown, nil, args.Pretty))
+		return nil
+	}
+	ser := parser.NewSerialiser(styler, false)
+	fmt.Print(parser.SerialiseRecords(ser, shown...).ToString())
+	return nil
+}
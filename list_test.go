@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"ktask/ktask"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const listContent = `todo
+    2024-01-02 2024-01-05 buy milk #grocery #prio=b #id=aaaaaa
+    2024-01-03 2024-01-03 water plants #due=2024-02-01 #id=bbbbbb
+    2024-01-01 2024-01-04 call mom #prio=a #id=cccccc
+
+done
+    2024-01-01 2024-01-02 celebrate #id=dddddd
+`
+
+func TestListData(t *testing.T) {
+	path := tempFile(t, listContent)
+	for _, test := range []struct {
+		args   argList
+		expect string
+	}{
+		{argList{}, listContent},
+		{argList{Stage: []string{"done"}}, "done\n    2024-01-01 2024-01-02 celebrate #id=dddddd\n"},
+		{argList{Tags: []string{"grocery"}, Stage: []string{"todo"}}, "todo\n    2024-01-02 2024-01-05 buy milk #grocery #prio=b #id=aaaaaa\n"},
+		{argList{NoTags: []string{"grocery", "prio"}, Stage: []string{"todo"}}, "todo\n    2024-01-03 2024-01-03 water plants #due=2024-02-01 #id=bbbbbb\n"},
+		{argList{Prio: "b", Sort: "prio", Stage: []string{"todo"}}, "todo\n    2024-01-01 2024-01-04 call mom #prio=a #id=cccccc\n    2024-01-02 2024-01-05 buy milk #grocery #prio=b #id=aaaaaa\n"},
+		{argList{DueBefore: day(2024, 2, 1), Stage: []string{"todo"}}, "todo\n    2024-01-03 2024-01-03 water plants #due=2024-02-01 #id=bbbbbb\n"},
+		{argList{CreatedAfter: day(2024, 1, 2), ModifiedBefore: day(2024, 1, 4), Stage: []string{"todo"}}, "todo\n    2024-01-03 2024-01-03 water plants #due=2024-02-01 #id=bbbbbb\n"},
+		{argList{Sort: "created", Stage: []string{"todo"}}, "todo\n    2024-01-01 2024-01-04 call mom #prio=a #id=cccccc\n    2024-01-02 2024-01-05 buy milk #grocery #prio=b #id=aaaaaa\n    2024-01-03 2024-01-03 water plants #due=2024-02-01 #id=bbbbbb\n"},
+	} {
+		test.args.File, test.args.Format = path, "plain"
+		out := captureStdout(t, func() {
+			require.Nil(t, listData(&test.args, config{}))
+		})
+		assert.Equal(t, test.expect, out, test.args)
+	}
+}
+
+func TestListDataRejectsInvalidArguments(t *testing.T) {
+	path := tempFile(t, listContent)
+	for _, args := range []argList{
+		{Format: "xml"},
+		{Format: "plain", Sort: "title"},
+		{Format: "plain", Prio: "1"},
+	} {
+		args.File = path
+		assert.Equal(t, ktask.LOGICAL_ERROR, errorCode(listData(&args, config{})), args)
+	}
+}
+
+func TestListDataReportsErrors(t *testing.T) {
+	path := tempFile(t, "todo\n    2024-1-02 2024-01-03 buy milk\n")
+	var err error
+	out := captureStdout(t, func() {
+		err = listData(&argList{File: path, Format: "json"}, config{})
+	})
+	assert.Contains(t, out, `"errors":[{`)
+	var errK ktask.Error
+	require.ErrorAs(t, err, &errK)
+	assert.Equal(t, ktask.LOGICAL_ERROR, errK.Code())
+
+	out = captureStdout(t, func() {
+		err = listData(&argList{File: path, Format: "plain"}, config{})
+	})
+	assert.Empty(t, out)
+	_, ok := err.(ktask.ParserErrors)
+	assert.True(t, ok)
+}
+
+func day(year int, month int, d int) date {
+	return date{time.Date(year, time.Month(month), d, 0, 0, 0, 0, time.UTC)}
+}
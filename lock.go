@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"ktask/ktask"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lockInfo describes the process holding a lock. It is stored in the lock
+// file as key=value lines.
+type lockInfo struct {
+	pid     int
+	host    string
+	created time.Time
+}
+
+func newLockInfo() lockInfo {
+	host, _ := os.Hostname()
+	return lockInfo{pid: os.Getpid(), host: host, created: time.Now()}
+}
+
+func (l lockInfo) ToString() string {
+	return fmt.Sprintf("pid=%d\nhost=%s\ncreated=%s\n", l.pid, l.host, l.created.Format(time.RFC3339))
+}
+
+func (l lockInfo) describe() string {
+	return fmt.Sprintf("process %d on %s since %s", l.pid, l.host, l.created.Format("2006-01-02 15:04:05"))
+}
+
+func parseLockInfo(content string) (lockInfo, error) {
+	var l lockInfo
+	var err error
+	for _, line := range strings.Split(content, "\n") {
+		k, v, _ := strings.Cut(line, "=")
+		switch k {
+		case "pid":
+			l.pid, err = strconv.Atoi(v)
+		case "host":
+			l.host = v
+		case "created":
+			l.created, err = time.Parse(time.RFC3339, v)
+		}
+		if err != nil {
+			return l, err
+		}
+	}
+	if l.pid == 0 || l.host == "" {
+		return l, errors.New("incomplete lock file")
+	}
+	return l, nil
+}
+
+// stale reports whether the process holding the lock doesn't exist anymore.
+// Locks held by other hosts can't be checked and are never stale.
+func (l lockInfo) stale() bool {
+	host, _ := os.Hostname()
+	return l.host == host && !processAlive(l.pid)
+}
+
+// heldLocks are the lock files created by this process, so they can be
+// released on every exit path.
+var heldLocks = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: map[string]bool{}}
+
+// acquireLock creates the lock file of the source exclusively. If the lock is
+// stale (or can't be read at all), the user is offered to break it.
+func acquireLock(source string) ktask.Error {
+	lock := source + ".lock"
+	own := newLockInfo().ToString()
+	broken := false
+	for {
+		err := createLock(lock, own)
+		if err == nil {
+			heldLocks.Lock()
+			heldLocks.paths[lock] = true
+			heldLocks.Unlock()
+			// another process which broke the same stale lock might have
+			// replaced ours right away
+			if current, err := os.ReadFile(lock); err != nil || string(current) != own {
+				heldLocks.Lock()
+				delete(heldLocks.paths, lock)
+				heldLocks.Unlock()
+				return ktask.NewError(
+					"File is locked",
+					"Another process took over the lock of the file at the same time. If that is not the case, remove "+lock,
+					errors.New("file exists"),
+				)
+			}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return ktask.NewErrorWithCode(ktask.IO_ERROR, "Cannot create lock file", lock, err)
+		}
+
+		content, rErr := os.ReadFile(lock)
+		if errors.Is(rErr, fs.ErrNotExist) {
+			// released in the meantime
+			continue
+		}
+		info, pErr := parseLockInfo(string(content))
+		if pErr == nil && !info.stale() {
+			return ktask.NewError(
+				"File is locked",
+				"The file is in use by "+info.describe()+". If that is not the case, remove "+lock,
+				errors.New("file exists"),
+			)
+		}
+		question := "The lock file " + lock + " can't be read. Break the lock?"
+		if pErr == nil {
+			question = "The lock file " + lock + " was left behind by " + info.describe() + ", which doesn't exist anymore. Break the lock?"
+		}
+		if broken || !confirm(question) {
+			return ktask.NewError(
+				"Stale lock file exists",
+				"The lock file "+lock+" is stale, remove it to continue",
+				errors.New("file exists"),
+			)
+		}
+		broken = true
+		// only the lock which was found to be stale is removed, someone else
+		// might have broken it and created a new one meanwhile
+		if current, err := os.ReadFile(lock); err == nil && string(current) != string(content) {
+			continue
+		}
+		if err := os.Remove(lock); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return ktask.NewErrorWithCode(ktask.IO_ERROR, "Cannot remove stale lock file", lock, err)
+		}
+	}
+}
+
+// createLock creates the lock file with the content. The content is written
+// to a temporary file first, which is then linked into place, so that other
+// processes never see an incomplete lock file. It fails with fs.ErrExist if
+// the lock file exists already.
+func createLock(lock string, content string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(lock), filepath.Base(lock)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(content)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Link(tmp.Name(), lock); err == nil || errors.Is(err, fs.ErrExist) {
+		return err
+	}
+
+	// the file system doesn't support hard links, the content can only be
+	// written after creating the file exclusively
+	f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		os.Remove(lock)
+	}
+	return err
+}
+
+// releaseLock removes the lock of the source if it was created by this
+// process.
+func releaseLock(source string) error {
+	lock := source + ".lock"
+	heldLocks.Lock()
+	defer heldLocks.Unlock()
+	if !heldLocks.paths[lock] {
+		return nil
+	}
+	delete(heldLocks.paths, lock)
+	return os.Remove(lock)
+}
+
+// releaseLocks removes all locks held by this process.
+func releaseLocks() {
+	heldLocks.Lock()
+	defer heldLocks.Unlock()
+	for lock := range heldLocks.paths {
+		os.Remove(lock)
+	}
+	heldLocks.paths = map[string]bool{}
+}
+
+// releaseOnSignal releases all locks and exits, when one of the signals is
+// received. The returned function stops handling the signals.
+func releaseOnSignal(sigs ...os.Signal) func() {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case <-ch:
+			releaseLocks()
+			os.Exit(int(ktask.GENERAL_ERROR))
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// confirm asks a yes/no question on the terminal. If stdin is not a
+// terminal, the answer is no. It is a variable, so tests can answer.
+var confirm = func(question string) bool {
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	fmt.Fprint(os.Stderr, question+" [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deadPid is a process identifier which is not in use, as it is above the
+// maximum of Linux.
+const deadPid = 1 << 23
+
+func TestParseLockInfo(t *testing.T) {
+	l, err := parseLockInfo("pid=42\nhost=box\ncreated=2024-01-02T10:00:00Z\n")
+	require.Nil(t, err)
+	assert.Equal(t, lockInfo{42, "box", time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)}, l)
+
+	// the lock of the own process can be read back
+	own := newLockInfo()
+	l, err = parseLockInfo(own.ToString())
+	require.Nil(t, err)
+	assert.Equal(t, own.pid, l.pid)
+	assert.Equal(t, own.host, l.host)
+
+	for _, content := range []string{
+		"",
+		"garbage",
+		"pid=42\n",
+		"host=box\n",
+		"pid=x\nhost=box\n",
+		"pid=42\nhost=box\ncreated=yesterday\n",
+	} {
+		_, err := parseLockInfo(content)
+		assert.Error(t, err, content)
+	}
+}
+
+func TestLockInfoStale(t *testing.T) {
+	host, _ := os.Hostname()
+	assert.False(t, lockInfo{pid: os.Getpid(), host: host}.stale())
+	assert.True(t, lockInfo{pid: deadPid, host: host}.stale())
+	// can't be checked
+	assert.False(t, lockInfo{pid: deadPid, host: host + "-other"}.stale())
+}
+
+// answer makes confirm answer the questions, the questions are returned.
+func answer(t *testing.T, yes bool, before func()) *[]string {
+	var questions []string
+	original := confirm
+	confirm = func(question string) bool {
+		questions = append(questions, question)
+		if before != nil {
+			before()
+		}
+		return yes
+	}
+	t.Cleanup(func() { confirm = original })
+	return &questions
+}
+
+func writeLock(t *testing.T, path string, l lockInfo) {
+	require.Nil(t, os.WriteFile(path+".lock", []byte(l.ToString()), 0o644))
+}
+
+func TestAcquireLock(t *testing.T) {
+	path := tempFile(t, "todo\n")
+	questions := answer(t, true, nil)
+	require.Nil(t, acquireLock(path))
+	content, _ := os.ReadFile(path + ".lock")
+	l, err := parseLockInfo(string(content))
+	require.Nil(t, err)
+	assert.Equal(t, os.Getpid(), l.pid)
+
+	// locked by a process which is alive
+	errK := acquireLock(path)
+	require.NotNil(t, errK)
+	assert.Equal(t, "File is locked", errK.Error())
+
+	require.Nil(t, releaseLock(path))
+	assert.False(t, exists(path+".lock"))
+	assert.Empty(t, *questions)
+}
+
+func TestCreateLock(t *testing.T) {
+	lock := tempFile(t, "todo\n") + ".lock"
+	require.Nil(t, createLock(lock, "pid=1\n"))
+	content, _ := os.ReadFile(lock)
+	assert.Equal(t, "pid=1\n", string(content))
+	fi, _ := os.Stat(lock)
+	assert.Equal(t, os.FileMode(0o644), fi.Mode().Perm())
+
+	// an existing lock is kept
+	assert.ErrorIs(t, createLock(lock, "pid=2\n"), fs.ErrExist)
+	content, _ = os.ReadFile(lock)
+	assert.Equal(t, "pid=1\n", string(content))
+
+	// no temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(lock))
+	assert.Len(t, entries, 2)
+}
+
+func TestAcquireLockBreaksStaleLock(t *testing.T) {
+	host, _ := os.Hostname()
+	for _, content := range []string{
+		lockInfo{pid: deadPid, host: host, created: time.Now()}.ToString(),
+		"garbage",
+	} {
+		path := tempFile(t, "todo\n")
+		require.Nil(t, os.WriteFile(path+".lock", []byte(content), 0o644))
+
+		// refused
+		questions := answer(t, false, nil)
+		errK := acquireLock(path)
+		require.NotNil(t, errK)
+		assert.Equal(t, "Stale lock file exists", errK.Error())
+		assert.Len(t, *questions, 1)
+
+		// accepted
+		questions = answer(t, true, nil)
+		require.Nil(t, acquireLock(path))
+		assert.Len(t, *questions, 1)
+		written, _ := os.ReadFile(path + ".lock")
+		l, _ := parseLockInfo(string(written))
+		assert.Equal(t, os.Getpid(), l.pid)
+		releaseLock(path)
+	}
+}
+
+func TestAcquireLockKeepsForeignLock(t *testing.T) {
+	host, _ := os.Hostname()
+	path := tempFile(t, "todo\n")
+	writeLock(t, path, lockInfo{pid: deadPid, host: host + "-other", created: time.Now()})
+	questions := answer(t, true, nil)
+	errK := acquireLock(path)
+	require.NotNil(t, errK)
+	assert.Equal(t, "File is locked", errK.Error())
+	assert.Empty(t, *questions)
+	assert.True(t, exists(path+".lock"))
+}
+
+func TestAcquireLockDoesntBreakNewLock(t *testing.T) {
+	host, _ := os.Hostname()
+	path := tempFile(t, "todo\n")
+	writeLock(t, path, lockInfo{pid: deadPid, host: host, created: time.Now()})
+	// someone else broke the stale lock while the user was asked
+	alive := lockInfo{pid: os.Getppid(), host: host, created: time.Now()}
+	answer(t, true, func() { writeLock(t, path, alive) })
+	errK := acquireLock(path)
+	require.NotNil(t, errK)
+	assert.Equal(t, "File is locked", errK.Error())
+	content, _ := os.ReadFile(path + ".lock")
+	assert.Equal(t, alive.ToString(), string(content))
+	// it isn't released as it isn't ours
+	require.Nil(t, releaseLock(path))
+	assert.True(t, exists(path+".lock"))
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// processAlive checks whether a process with the pid exists. On windows
+// finding a process fails if it doesn't exist.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
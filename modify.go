@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type argAdd struct {
+	File  string   `arg:"--file,-f" help:"specify the file that should be read from / written to"`
+	Stage string   `arg:"--stage,-s" help:"the stage the entry is added to, defaults to the first stage"`
+	Tags  []string `arg:"--tags,-t,separate" help:"tag(s) of the entry, the first one is the project, may be specified multiple times"`
+	Title []string `arg:"positional,required" help:"title of the entry"`
+}
+
+type argMove struct {
+	File  string `arg:"--file,-f" help:"specify the file that should be read from / written to"`
+	Task  string `arg:"positional,required" help:"the entry to move, either its id or <stage>:<position> (starting at 1)"`
+	Stage string `arg:"positional,required" help:"the stage the entry is moved to"`
+}
+
+type argEdit struct {
+	File      string   `arg:"--file,-f" help:"specify the file that should be read from / written to"`
+	Task      string   `arg:"positional,required" help:"the entry to edit, either its id or <stage>:<position> (starting at 1)"`
+	Title     string   `arg:"--title" help:"the new title of the entry, the tags are kept"`
+	Tags      []string `arg:"--tags,-t,separate" help:"replace the tags of the entry with this/these tag(s), may be specified multiple times"`
+	ClearTags bool     `arg:"--clear-tags" help:"remove all tags from the entry"`
+}
+
+type argRm struct {
+	File string `arg:"--file,-f" help:"specify the file that should be read from / written to"`
+	Task string `arg:"positional,required" help:"the entry to remove, either its id or <stage>:<position> (starting at 1)"`
+}
+
+// modifyData reads the file, applies the modification and writes the result
+// back. The modification gets the stages the file was read with. If it fails,
+// the file is left untouched.
+func modifyData(path string, cfg config, modify func([]ktask.Record, ktask.Stages) ([]ktask.Record, error)) error {
+	data, file, errK := readData(path, cfg.stages, false)
+	if errK != nil {
+		return errK
+	}
+	data, err := modify(data, file.stages)
+	if err != nil {
+		releaseLock(path)
+		return err
+	}
+	_, conflicts, err := writeData(file, data, cfg.backups)
+	if err != nil {
+		return err
+	}
+	for _, c := range describeConflicts(conflicts) {
+		fmt.Fprintln(os.Stderr, c)
+	}
+	return releaseLock(path)
+}
+
+// refDetails explains the references to entries.
+const refDetails = "An entry is referenced by its id or by <stage>:<position>, e.g. todo:1."
+
+// findEntry resolves a reference to the indices of the record and the entry.
+// The reference is either the identifier of the entry or has the form
+// <stage>:<position>.
+func findEntry(data []ktask.Record, ref string) (int, int, error) {
+	id := strings.TrimPrefix(strings.TrimPrefix(ref, "#"), ktask.IdTagName+"=")
+	for ri, r := range data {
+		for ei, e := range r.Entries() {
+			if id != "" && e.ID() == id {
+				return ri, ei, nil
+			}
+		}
+	}
+	sep := strings.LastIndex(ref, ":")
+	if sep < 0 {
+		return 0, 0, usageError(refDetails, "no entry with id %q", id)
+	}
+	pos, err := strconv.Atoi(ref[sep+1:])
+	if err != nil {
+		return 0, 0, usageError(refDetails, "invalid position in reference %q", ref)
+	}
+	ri := findRecord(data, ktask.Stage(ref[:sep]))
+	if ri < 0 {
+		return 0, 0, usageError(refDetails, "no stage %q", ref[:sep])
+	}
+	if pos < 1 || pos > len(data[ri].Entries()) {
+		return 0, 0, usageError(refDetails, "no entry at position %d in stage %q", pos, ref[:sep])
+	}
+	return ri, pos - 1, nil
+}
+
+// entryRef returns the identifier of the entry, or if it has none, its
+// reference in the form <stage>:<position>.
+func entryRef(r ktask.Record, i int) string {
+	if id := r.Entries()[i].ID(); id != "" {
+		return id
+	}
+	return fmt.Sprintf("%s:%d", r.Stage(), i+1)
+}
+
+// findRecord returns the index of the (first) record with the stage or -1.
+func findRecord(data []ktask.Record, stage ktask.Stage) int {
+	for i, r := range data {
+		if r.Stage() == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+// stageRecord returns the index of the record with the stage, creating a new
+// record if the stage doesn't exist yet (and is valid).
+func stageRecord(data []ktask.Record, stages ktask.Stages, stage ktask.Stage) ([]ktask.Record, int, error) {
+	if i := findRecord(data, stage); i >= 0 {
+		return data, i, nil
+	}
+	if err := stages.Valid(stage); err != nil {
+		return data, -1, usageError(err.Error(), "invalid stage %q", stage)
+	}
+	return append(data, ktask.NewRecord(stage)), len(data), nil
+}
+
+func parseTags(ss []string) ([]ktask.Tag, error) {
+	var tags []ktask.Tag
+	for _, s := range ss {
+		t, err := ktask.NewTagFromString(s)
+		if err != nil {
+			return nil, usageError(`A tag is written like #name, #name=value or #name="some value".`, "invalid tag %q", s)
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+func addEntry(args *argAdd, cfg config) error {
+	tags, err := parseTags(args.Tags)
+	if err != nil {
+		return err
+	}
+	path := filePath(args.File)
+	var id string
+	err = modifyData(path, cfg, func(data []ktask.Record, stages ktask.Stages) ([]ktask.Record, error) {
+		stage := ktask.Stage(args.Stage)
+		if stage == "" {
+			if len(data) == 0 {
+				return nil, usageError("The file doesn't contain any stage yet.", "no stage to add the entry to, please specify one")
+			}
+			stage = data[0].Stage()
+		}
+		data, ri, err := stageRecord(data, stages, stage)
+		if err != nil {
+			return nil, err
+		}
+		name := ktask.Name{strings.Join(args.Title, " ")}.WithTags(tags...)
+		now := time.Now()
+		e := ktask.NewEntry(name, now, now, len(data[ri].Entries()))
+		e.SetID(ktask.NewID(data...))
+		data[ri].AppendEntry(e)
+		id = e.ID()
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(id)
+	return nil
+}
+
+func moveEntry(args *argMove, cfg config) error {
+	path := filePath(args.File)
+	var ref string
+	err := modifyData(path, cfg, func(data []ktask.Record, stages ktask.Stages) ([]ktask.Record, error) {
+		ri, ei, err := findEntry(data, args.Task)
+		if err != nil {
+			return nil, err
+		}
+		data, ti, err := stageRecord(data, stages, ktask.Stage(args.Stage))
+		if err != nil {
+			return nil, err
+		}
+		e := data[ri].RemoveEntry(ei)
+		e.SetModified()
+		data[ti].AppendEntry(e)
+		ref = entryRef(data[ti], len(data[ti].Entries())-1)
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(ref)
+	return nil
+}
+
+func editEntry(args *argEdit, cfg config) error {
+	if args.Title == "" && len(args.Tags) == 0 && !args.ClearTags {
+		return usageError("Use --title, --tags or --clear-tags.", "nothing to edit, please specify a new title and/or tags")
+	}
+	newTags, err := parseTags(args.Tags)
+	if err != nil {
+		return err
+	}
+	path := filePath(args.File)
+	return modifyData(path, cfg, func(data []ktask.Record, stages ktask.Stages) ([]ktask.Record, error) {
+		ri, ei, err := findEntry(data, args.Task)
+		if err != nil {
+			return nil, err
+		}
+		e := &data[ri].Entries()[ei]
+		name := e.Name()
+		if args.Title != "" {
+			name = name.WithTitle(args.Title)
+		}
+		if len(newTags) > 0 || args.ClearTags {
+			name = name.ReplaceTags(newTags...)
+		}
+		e.SetName(name)
+		e.SetModified()
+		return data, nil
+	})
+}
+
+func removeEntry(args *argRm, cfg config) error {
+	path := filePath(args.File)
+	return modifyData(path, cfg, func(data []ktask.Record, stages ktask.Stages) ([]ktask.Record, error) {
+		ri, ei, err := findEntry(data, args.Task)
+		if err != nil {
+			return nil, err
+		}
+		data[ri].RemoveEntry(ei)
+		return data, nil
+	})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"ktask/ktask"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const modifyContent = `todo
+    2024-01-02 2024-01-02 #grocery buy milk #prio=b #id=aaaaaa
+    2024-01-03 2024-01-03 water #garden plants
+        in the living room #where="first floor"
+        notes
+    2024-01-04 2024-01-04 call mom
+
+done
+`
+
+// modified runs fn on a file with modifyContent and returns what it printed
+// and the content of the file afterwards.
+func modified(t *testing.T, fn func(path string) error) (string, string) {
+	path := tempFile(t, modifyContent)
+	var err error
+	out := captureStdout(t, func() { err = fn(path) })
+	require.Nil(t, err)
+	content, _ := os.ReadFile(path)
+	return out, string(content)
+}
+
+func TestAddEntry(t *testing.T) {
+	out, content := modified(t, func(path string) error {
+		return addEntry(&argAdd{File: path, Tags: []string{"work", "prio=a"}, Title: []string{"write", "report"}}, config{})
+	})
+	require.Regexp(t, `^[a-z0-9]+\n$`, out)
+	assert.Regexp(t, `\n    \d{4}-\d{2}-\d{2} \d{4}-\d{2}-\d{2} #work #prio=a write report #id=`+out[:len(out)-1]+`\n\ndone\n$`, content)
+
+	out, content = modified(t, func(path string) error {
+		return addEntry(&argAdd{File: path, Stage: "done", Title: []string{"celebrate"}}, config{})
+	})
+	assert.Regexp(t, `done\n    \d{4}-\d{2}-\d{2} \d{4}-\d{2}-\d{2} celebrate #id=`+out[:len(out)-1]+`\n$`, content)
+}
+
+func TestAddEntryPrintsNothingIfWritingFails(t *testing.T) {
+	path := tempFile(t, modifyContent)
+	var err error
+	out := captureStdout(t, func() {
+		err = addEntry(&argAdd{File: path, Stage: "Doing", Title: []string{"x"}}, config{stages: ktask.Stages{"todo", "done"}})
+	})
+	assert.Error(t, err)
+	assert.Empty(t, out)
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, modifyContent, string(content))
+}
+
+func TestMoveEntry(t *testing.T) {
+	out, content := modified(t, func(path string) error {
+		return moveEntry(&argMove{File: path, Task: "todo:3", Stage: "done"}, config{})
+	})
+	assert.Equal(t, "done:1\n", out)
+	assert.Regexp(t, `\n\ndone\n    2024-01-04 \d{4}-\d{2}-\d{2} call mom\n$`, content)
+
+	out, _ = modified(t, func(path string) error {
+		return moveEntry(&argMove{File: path, Task: "#id=aaaaaa", Stage: "waiting"}, config{})
+	})
+	assert.Equal(t, "aaaaaa\n", out)
+}
+
+func TestEditEntryKeepsTagPositions(t *testing.T) {
+	for _, test := range []struct {
+		args   argEdit
+		expect string
+	}{
+		{argEdit{Task: "aaaaaa", Title: "buy oat milk"}, "#grocery buy oat milk #prio=b #id=aaaaaa"},
+		{argEdit{Task: "aaaaaa", Tags: []string{"grocery", "prio=a"}}, "#grocery buy milk #prio=a #id=aaaaaa"},
+		{argEdit{Task: "aaaaaa", Tags: []string{"prio=b", "shop"}}, "#shop buy milk #prio=b #id=aaaaaa"},
+		{argEdit{Task: "aaaaaa", ClearTags: true}, "buy milk #id=aaaaaa"},
+		{argEdit{Task: "todo:2", Title: "water"}, "water #garden\n        in the living room #where=\"first floor\""},
+		{argEdit{Task: "todo:2", Tags: []string{"garden", "due=2024-02-01"}}, "water #garden plants #due=2024-02-01\n        in the living room #where=\"first floor\""},
+		{argEdit{Task: "todo:2", Tags: []string{"where=kitchen", "garden"}}, "#where=kitchen water #garden plants"},
+	} {
+		path := tempFile(t, modifyContent)
+		test.args.File = path
+		require.Nil(t, editEntry(&test.args, config{}))
+		content, _ := os.ReadFile(path)
+		assert.Regexp(t, `\n    \d{4}-\d{2}-\d{2} \d{4}-\d{2}-\d{2} `+regexp.QuoteMeta(test.expect)+`\n`, string(content), test.args)
+	}
+}
+
+func TestRemoveEntry(t *testing.T) {
+	_, content := modified(t, func(path string) error {
+		return removeEntry(&argRm{File: path, Task: "todo:2"}, config{})
+	})
+	assert.Equal(t, `todo
+    2024-01-02 2024-01-02 #grocery buy milk #prio=b #id=aaaaaa
+    2024-01-04 2024-01-04 call mom
+
+done
+`, content)
+
+	path := tempFile(t, modifyContent)
+	for _, ref := range []string{"bbbbbb", "todo:4", "todo:x", "doing:1"} {
+		err := removeEntry(&argRm{File: path, Task: ref}, config{})
+		assert.Equal(t, ktask.LOGICAL_ERROR, errorCode(err), ref)
+	}
+}
+
+func TestModifyReportsMistakesWithExitCode(t *testing.T) {
+	path := tempFile(t, modifyContent)
+	cfg := config{stages: ktask.Stages{"todo", "done"}}
+	for _, err := range []error{
+		addEntry(&argAdd{File: path, Stage: "blocked", Title: []string{"x"}}, cfg),
+		addEntry(&argAdd{File: path, Tags: []string{"a b"}, Title: []string{"x"}}, cfg),
+		moveEntry(&argMove{File: path, Task: "todo:1", Stage: "blocked"}, cfg),
+		editEntry(&argEdit{File: path, Task: "todo:1"}, cfg),
+		editEntry(&argEdit{File: path, Task: "nosuch", Title: "x"}, cfg),
+	} {
+		assert.Equal(t, ktask.LOGICAL_ERROR, errorCode(err), err)
+	}
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, modifyContent, string(content))
+}
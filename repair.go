@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"ktask/ktask"
+	"ktask/ktask/parser"
+	"os"
+
+	tf "github.com/jotaen/klog/klog/app/cli/terminalformat"
+)
+
+type argRepair struct {
+	File string `arg:"positional" help:"specify the file that should be repaired"`
+	Yes  bool   `arg:"--yes,-y" help:"write the repaired file without asking"`
+}
+
+// repairFile repairs common errors in the file. The changes are shown as diff
+// and written after confirmation. Errors which can't be repaired are reported
+// but only make this fail if nothing could be repaired.
+func repairFile(path string, cfg config, yes bool) error {
+	if errK := acquireLock(path); errK != nil {
+		return errK
+	}
+	defer releaseLock(path)
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return ktask.NewErrorWithCode(ktask.NO_INPUT_ERROR, "Error reading file", "Location: "+path, err)
+	}
+
+	stages, errK := fileStages(string(original), cfg.stages)
+	if errK != nil {
+		return errK
+	}
+	repaired, repairs, errs := parser.Repair(string(original), stages)
+	if len(repairs) == 0 {
+		if errs != nil {
+			return ktask.NewParserErrors(errs)
+		}
+		fmt.Println("nothing to repair in " + path)
+		return nil
+	}
+
+	fmt.Print(diff(path, string(original), repaired))
+	for _, r := range repairs {
+		fmt.Println(r)
+	}
+	if errs != nil {
+		fmt.Fprintf(os.Stderr, "%d error(s) can't be repaired automatically:\n", len(errs))
+		fmt.Fprintln(os.Stderr, ktask.PrettifyParsingError(ktask.NewParserErrors(errs), tf.NewStyler(tf.COLOUR_THEME_NO_COLOUR)))
+	}
+	if !yes && !confirm("Write the repaired file?") {
+		return nil
+	}
+
+	if err := backupFile(path, max(cfg.backups, 1)); err != nil {
+		return fmt.Errorf("making backup before repairing failed: %w", err)
+	}
+	return writeFileAtomic(path, []byte(repaired), 0o644)
+}
+
+// fixFile offers to repair the file before opening the board, if it can't be
+// read.
+func fixFile(path string, cfg config) error {
+	if _, _, errK := loadData(path, cfg.stages); errK == nil {
+		return nil
+	}
+	return repairFile(path, cfg, false)
+}
+
+func repairData(args *argRepair, cfg config) error {
+	return repairFile(filePath(args.File), cfg, args.Yes)
+}
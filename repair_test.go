@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"ktask/ktask"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const brokenContent = "todo\n    2024-01-02 buy milk\n"
+
+const repairedContent = "todo\n    2024-01-02 2024-01-02 buy milk\n"
+
+func TestRepairFileShowsDiffAndAsks(t *testing.T) {
+	path := tempFile(t, brokenContent)
+	questions := answer(t, false, nil)
+	out := captureStdout(t, func() {
+		require.Nil(t, repairFile(path, config{}, false))
+	})
+	assert.Contains(t, out, "-    2024-01-02 buy milk\n+    2024-01-02 2024-01-02 buy milk\n")
+	assert.Contains(t, out, "added the missing modification date: 2024-01-02 buy milk\n")
+	assert.Equal(t, []string{"Write the repaired file?"}, *questions)
+
+	// nothing is written without confirmation
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, brokenContent, string(content))
+	backups, _ := listBackups(path)
+	assert.Empty(t, backups)
+}
+
+func TestRepairFileWritesAfterConfirmation(t *testing.T) {
+	path := tempFile(t, brokenContent)
+	answer(t, true, nil)
+	captureStdout(t, func() {
+		require.Nil(t, repairFile(path, config{}, false))
+	})
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, repairedContent, string(content))
+
+	// a backup is made, even if backups are disabled
+	backups, _ := listBackups(path)
+	require.Len(t, backups, 1)
+	content, _ = os.ReadFile(backups[0].path)
+	assert.Equal(t, brokenContent, string(content))
+
+	out := captureStdout(t, func() {
+		require.Nil(t, repairFile(path, config{}, false))
+	})
+	assert.Equal(t, "nothing to repair in "+path+"\n", out)
+}
+
+func TestRepairDataWithoutAsking(t *testing.T) {
+	path := tempFile(t, brokenContent)
+	questions := answer(t, false, nil)
+	captureStdout(t, func() {
+		require.Nil(t, repairData(&argRepair{File: path, Yes: true}, config{}))
+	})
+	assert.Empty(t, *questions)
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, repairedContent, string(content))
+}
+
+func TestFixFile(t *testing.T) {
+	// readable files are left alone
+	path := tempFile(t, "todo\n    2024-01-02 2024-01-03 buy milk\n")
+	questions := answer(t, true, nil)
+	require.Nil(t, fixFile(path, config{}))
+	assert.Empty(t, *questions)
+
+	path = tempFile(t, brokenContent)
+	captureStdout(t, func() {
+		require.Nil(t, fixFile(path, config{}))
+	})
+	assert.Len(t, *questions, 1)
+	_, _, errK := loadData(path, nil)
+	assert.Nil(t, errK)
+
+	// errors which can't be repaired are reported like other parser errors
+	path = tempFile(t, "todo\n    2024-1-02 2024-01-03 buy milk\n")
+	err := fixFile(path, config{})
+	pErrs, ok := err.(ktask.ParserErrors)
+	require.True(t, ok, err)
+	assert.Len(t, pErrs.All(), 1)
+}